@@ -0,0 +1,138 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+	"github.com/ldmonster/flac-splitter/internal/flacsplitter"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [path]",
+	Short: "Validate CUE sheets and their audio files without splitting anything",
+	Long: `Check parses every CUE sheet under path (default: the current directory), or
+the single CUE file given, and reports CUE sheets that cannot be parsed, audio
+files that are missing, FLAC files whose duration does not fit the CUE, invalid
+ISRCs and indexes that do not increase. Nothing is written. The exit status is
+1 when any problem was found, so it can run from cron; warnings alone do not
+fail the check. Use --verbose to also list albums without problems.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) {
+	root := "."
+	if len(args) == 1 {
+		root = args[0]
+	}
+
+	var cueFiles []cueparser.CueFile
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		cue, err := singleCueFile(root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitFailed)
+		}
+		cueFiles = append(cueFiles, cue)
+	} else {
+		found, err := cueparser.FindAllWithOptions(root, cueparser.DefaultFindOptions())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding CUE files: %v\n", err)
+			os.Exit(exitFailed)
+		}
+		cueFiles = found
+	}
+
+	failed := 0
+	for _, cue := range cueFiles {
+		report := checkCue(cue)
+		if len(report.Problems) > 0 {
+			failed++
+		}
+		if len(report.Problems) == 0 && (len(report.Warnings) == 0 || quiet) && !verbose {
+			continue
+		}
+
+		fmt.Println(cue.Path)
+		for _, problem := range report.Problems {
+			fmt.Printf("  ✗ %s\n", problem)
+		}
+		if !quiet {
+			for _, warning := range report.Warnings {
+				fmt.Printf("  Warning: %s\n", warning)
+			}
+		}
+		if len(report.Problems) == 0 && len(report.Warnings) == 0 {
+			fmt.Println("  ✓ OK")
+		}
+	}
+
+	fmt.Printf("\nChecked %d CUE file(s): %d with problems\n", len(cueFiles), failed)
+	if failed > 0 {
+		os.Exit(exitFailed)
+	}
+}
+
+// checkCue parses one CUE sheet, locates its audio and checks both
+func checkCue(cue cueparser.CueFile) flacsplitter.AlbumReport {
+	config := cueparser.DefaultConfig()
+	config.CollectWarnings = true
+	if err := cueparser.ParseWithConfig(&cue, config); err != nil {
+		var report flacsplitter.AlbumReport
+		if problems := validationProblems(err); problems != nil {
+			report.Problems = problems
+		} else {
+			report.Problems = []string{fmt.Sprintf("cannot parse CUE sheet: %v", err)}
+		}
+		return report
+	}
+
+	var warnings []string
+	for _, warning := range cue.Warnings {
+		warnings = append(warnings, warning.String())
+	}
+	warnings = append(warnings, cue.NumberingWarnings()...)
+
+	audioPath, err := cue.FindAudioFile()
+	if err != nil {
+		return flacsplitter.AlbumReport{Problems: []string{err.Error()}, Warnings: warnings}
+	}
+	if audioPath != cue.GetAudioFilePath() {
+		warnings = append(warnings, fmt.Sprintf("FILE entry %q not found, would use %s", cue.AudioFile, audioPath))
+	}
+
+	report := flacsplitter.CheckAlbum(cue, audioPath)
+	report.Warnings = append(warnings, report.Warnings...)
+	return report
+}
+
+// validationProblems returns the individual problems of a CUE validation
+// error, or nil for any other error
+func validationProblems(err error) []string {
+	var validationErr *cueparser.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Problems
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Config files read when --config is not given, the one in the working
+// directory taking precedence over the one in the user config directory
+const (
+	localConfigName = ".flac-splitter.yaml"
+	userConfigName  = "flac-splitter.yaml"
+)
+
+// configEntry is one "key: value" setting of a config file
+type configEntry struct {
+	section string // subcommand the setting is for, "" at the top level
+	key     string
+	values  []string
+	list    bool // written as a YAML list
+	line    int
+}
+
+// loadConfig sets the flags of cmd named in the config files, unless they
+// were given on the command line, so the precedence is flags, then the local
+// config file, then the user one, then the built-in defaults. It runs before
+// every command.
+func loadConfig(cmd *cobra.Command) error {
+	paths, err := configPaths()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		entries, err := readConfig(path)
+		if err != nil {
+			return err
+		}
+		if err := applyConfig(cmd, entries); err != nil {
+			return fmt.Errorf("%s:%w", path, err)
+		}
+		if verbose {
+			log.Printf("Read defaults from %s", path)
+		}
+	}
+	return nil
+}
+
+// configPaths returns the config files to apply, highest precedence first:
+// only --config when it is given, otherwise whichever of the local and user
+// config files exist
+func configPaths() ([]string, error) {
+	if configFile != "" {
+		if _, err := os.Stat(configFile); err != nil {
+			return nil, fmt.Errorf("cannot read --config file: %w", err)
+		}
+		return []string{configFile}, nil
+	}
+
+	candidates := []string{localConfigName}
+	if dir, err := os.UserConfigDir(); err == nil {
+		candidates = append(candidates, filepath.Join(dir, userConfigName))
+	}
+
+	var paths []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("cannot read config file: %w", err)
+		}
+	}
+	return paths, nil
+}
+
+// applyConfig sets the flags of cmd from entries. Top-level keys are flags
+// of the root command, of which only the persistent ones (--verbose,
+// --quiet) reach subcommands; a subcommand's own flags go in a section named
+// after it. Every entry is checked, whichever command it is for.
+func applyConfig(cmd *cobra.Command, entries []configEntry) error {
+	root := cmd.Root()
+	seen := make(map[[2]string]bool)
+	for _, entry := range entries {
+		id := [2]string{entry.section, entry.key}
+		if seen[id] {
+			return fmt.Errorf("%d: %s is set more than once", entry.line, entry.key)
+		}
+		seen[id] = true
+
+		target, err := configTarget(root, entry)
+		if err != nil {
+			return fmt.Errorf("%d: %w", entry.line, err)
+		}
+		persistent := root.PersistentFlags().Lookup(entry.key) != nil
+		if target != cmd && !(persistent && entry.section == "") {
+			continue
+		}
+		if err := applyConfigEntry(cmd.Flags(), entry); err != nil {
+			return fmt.Errorf("%d: %w", entry.line, err)
+		}
+	}
+	return nil
+}
+
+// configTarget returns the command entry sets a flag of, checking that the
+// flag exists
+func configTarget(root *cobra.Command, entry configEntry) (*cobra.Command, error) {
+	target := root
+	if entry.section != "" {
+		target = nil
+		for _, sub := range root.Commands() {
+			if sub.Name() == entry.section {
+				target = sub
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("unknown section %q", entry.section)
+		}
+	}
+
+	switch entry.key {
+	case "config", "help", "version":
+	default:
+		for _, flags := range []*pflag.FlagSet{target.Flags(), target.PersistentFlags(), root.PersistentFlags()} {
+			if flags.Lookup(entry.key) != nil {
+				return target, nil
+			}
+		}
+	}
+	if entry.section != "" {
+		return nil, fmt.Errorf("unknown option %q in section %s", entry.key, entry.section)
+	}
+	return nil, fmt.Errorf("unknown option %q", entry.key)
+}
+
+// applyConfigEntry sets the flag named by entry unless the command line
+// already set it
+func applyConfigEntry(flags *pflag.FlagSet, entry configEntry) error {
+	flag := flags.Lookup(entry.key)
+	if flag == nil {
+		return fmt.Errorf("unknown option %q", entry.key)
+	}
+	if flag.Changed {
+		return nil
+	}
+
+	typ := flag.Value.Type()
+	if entry.list && !strings.HasSuffix(typ, "Array") && !strings.HasSuffix(typ, "Slice") {
+		if len(entry.values) == 0 {
+			return fmt.Errorf("%s has no value", entry.key)
+		}
+		return fmt.Errorf("%s takes a single value, not a list", entry.key)
+	}
+	for _, value := range entry.values {
+		if err := flags.Set(entry.key, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", entry.key, err)
+		}
+	}
+	return nil
+}
+
+// readConfig parses a YAML config file: a mapping from long flag names to
+// scalars or lists of scalars, where a key holding a mapping instead is a
+// section of settings for the subcommand of that name
+func readConfig(path string) ([]configEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		// An empty file or only comments
+		return nil, nil
+	}
+	top := doc.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: expected \"key: value\" settings", path, top.Line)
+	}
+
+	var entries []configEntry
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		key, value := top.Content[i], top.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			entry, err := configValue("", key, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%w", path, err)
+			}
+			entries = append(entries, entry)
+			continue
+		}
+
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			entry, err := configValue(key.Value, value.Content[j], value.Content[j+1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%w", path, err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// configValue converts the key and value nodes of one setting in section
+func configValue(section string, key, value *yaml.Node) (configEntry, error) {
+	entry := configEntry{section: section, key: key.Value, line: key.Line}
+	if key.Kind != yaml.ScalarNode || key.Value == "" {
+		return entry, fmt.Errorf("%d: expected a flag name", key.Line)
+	}
+
+	switch {
+	case value.Kind == yaml.ScalarNode && value.Tag == "!!null":
+		// "key:" with nothing after it is an empty list
+		entry.list = true
+	case value.Kind == yaml.ScalarNode:
+		entry.values = []string{value.Value}
+	case value.Kind == yaml.SequenceNode:
+		entry.list = true
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return entry, fmt.Errorf("%d: %s: list items must be plain values", item.Line, entry.key)
+			}
+			entry.values = append(entry.values, item.Value)
+		}
+	default:
+		return entry, fmt.Errorf("%d: %s: expected a value or a list", value.Line, entry.key)
+	}
+	return entry, nil
+}
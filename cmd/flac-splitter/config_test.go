@@ -0,0 +1,191 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// writeConfig writes a config file with the given lines
+func writeConfig(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfig(t *testing.T) {
+	path := writeConfig(t,
+		"# defaults for every run",
+		"hybrid: true",
+		`layout: "{albumartist}/{year} - {album}" # quoted`,
+		"exclude:",
+		`  - "**/backup/**"`,
+		"  - '**/tmp/**'",
+		`tag-map: ["comment=COMMENT,DESCRIPTION", custom:SOURCE=SOURCE]`,
+		"include:",
+		"merge:",
+		"  block-size: 4608",
+	)
+
+	entries, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []configEntry{
+		{key: "hybrid", values: []string{"true"}, line: 2},
+		{key: "layout", values: []string{"{albumartist}/{year} - {album}"}, line: 3},
+		{key: "exclude", values: []string{"**/backup/**", "**/tmp/**"}, list: true, line: 4},
+		{key: "tag-map", values: []string{"comment=COMMENT,DESCRIPTION", "custom:SOURCE=SOURCE"}, list: true, line: 7},
+		{key: "include", list: true, line: 8},
+		{section: "merge", key: "block-size", values: []string{"4608"}, line: 10},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("readConfig() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestReadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"syntax", []string{"hybrid: [true"}, "yaml:"},
+		{"not a mapping", []string{"- hybrid"}, ":1: expected"},
+		{"nested list", []string{"exclude:", "  - [a, b]"}, ":2: exclude: list items"},
+		{"nested section", []string{"merge:", "  output:", "    dir: x"}, ":3: output: expected a value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readConfig(writeConfig(t, tt.lines...))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("readConfig() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadConfigEmpty(t *testing.T) {
+	entries, err := readConfig(writeConfig(t, "# nothing yet"))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("readConfig() = %v, %v, want no entries", entries, err)
+	}
+}
+
+// testCommands returns a root command with a persistent --verbose, a local
+// --output and --exclude, and a "merge" subcommand with its own --output
+func testCommands() (root, merge *cobra.Command) {
+	root = &cobra.Command{Use: "root"}
+	root.PersistentFlags().Bool("verbose", false, "")
+	root.Flags().String("output", "split", "")
+	root.Flags().StringArray("exclude", nil, "")
+	root.Flags().Bool("hybrid", false, "")
+
+	merge = &cobra.Command{Use: "merge"}
+	merge.Flags().String("output", "", "")
+	root.AddCommand(merge)
+	return root, merge
+}
+
+func TestApplyConfig(t *testing.T) {
+	entries := []configEntry{
+		{key: "verbose", values: []string{"true"}, line: 1},
+		{key: "output", values: []string{"/music"}, line: 2},
+		{key: "exclude", values: []string{"a", "b"}, list: true, line: 3},
+		{key: "hybrid", values: []string{"true"}, line: 4},
+		{section: "merge", key: "output", values: []string{"album.flac"}, line: 5},
+	}
+
+	t.Run("root", func(t *testing.T) {
+		root, _ := testCommands()
+		if err := root.ParseFlags([]string{"--hybrid=false"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyConfig(root, entries); err != nil {
+			t.Fatal(err)
+		}
+		flags := root.Flags()
+		for name, want := range map[string]string{
+			"verbose": "true", "output": "/music", "exclude": "[a,b]", "hybrid": "false",
+		} {
+			if got := flags.Lookup(name).Value.String(); got != want {
+				t.Errorf("--%s = %s, want %s", name, got, want)
+			}
+		}
+	})
+
+	t.Run("subcommand", func(t *testing.T) {
+		_, merge := testCommands()
+		if err := merge.ParseFlags(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyConfig(merge, entries); err != nil {
+			t.Fatal(err)
+		}
+		flags := merge.Flags()
+		if got := flags.Lookup("verbose").Value.String(); got != "true" {
+			t.Errorf("--verbose = %s, want the top-level true", got)
+		}
+		if got := flags.Lookup("output").Value.String(); got != "album.flac" {
+			t.Errorf("--output = %s, want the merge section's album.flac", got)
+		}
+	})
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry configEntry
+		want  string
+	}{
+		{"unknown key", configEntry{key: "bogus", values: []string{"1"}, line: 3}, `3: unknown option "bogus"`},
+		{"config key", configEntry{key: "config", values: []string{"x"}, line: 3}, `unknown option "config"`},
+		{"unknown section", configEntry{section: "split", key: "output", line: 4}, `4: unknown section "split"`},
+		{"root flag in section", configEntry{section: "merge", key: "hybrid", line: 5},
+			`unknown option "hybrid" in section merge`},
+		{"list for a single value", configEntry{key: "output", values: []string{"a", "b"}, list: true, line: 6},
+			"output takes a single value"},
+		{"bad value", configEntry{key: "hybrid", values: []string{"maybe"}, line: 7}, "invalid hybrid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, _ := testCommands()
+			if err := root.ParseFlags(nil); err != nil {
+				t.Fatal(err)
+			}
+			err := applyConfig(root, []configEntry{tt.entry})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("applyConfig() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	root, _ := testCommands()
+	twice := []configEntry{{key: "output", values: []string{"a"}, line: 1}, {key: "output", values: []string{"b"}, line: 2}}
+	if err := applyConfig(root, twice); err == nil || !strings.Contains(err.Error(), "set more than once") {
+		t.Errorf("applyConfig() error = %v, want a duplicate key error", err)
+	}
+}
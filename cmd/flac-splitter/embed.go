@@ -0,0 +1,118 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+	"github.com/ldmonster/flac-splitter/internal/flacsplitter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	embedOutputDir string
+	embedTags      bool
+)
+
+var embedCmd = &cobra.Command{
+	Use:   "embed [cue-file]",
+	Short: "Write one FLAC per album with the CUE sheet embedded instead of splitting",
+	Long: `Embed is the inverse of splitting: for every CUE sheet under the current
+directory, or the single CUE file given, it writes a copy of the album's FLAC
+with a CUESHEET metadata block built from the track offsets. The audio is not
+re-encoded. Use --tags to also replace the Vorbis comments with the album tags
+from the CUE sheet.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEmbed,
+}
+
+func init() {
+	embedCmd.Flags().StringVarP(&embedOutputDir, "output", "o", defaultOutputDir,
+		"Output directory for the FLAC files")
+	embedCmd.Flags().BoolVar(&embedTags, "tags", false,
+		"Replace the Vorbis comments with album tags from the CUE sheet")
+	embedCmd.Flags().BoolVar(&overwrite, "overwrite", false,
+		"Replace existing output files")
+	rootCmd.AddCommand(embedCmd)
+}
+
+func runEmbed(cmd *cobra.Command, args []string) error {
+	var cueFiles []cueparser.CueFile
+	if len(args) == 1 {
+		cue, err := singleCueFile(args[0])
+		if err != nil {
+			return err
+		}
+		cueFiles = append(cueFiles, cue)
+	} else {
+		findOpts := cueparser.DefaultFindOptions()
+		findOpts.SkipDirs = []string{embedOutputDir}
+		found, err := cueparser.FindAllWithOptions(".", findOpts)
+		if err != nil {
+			return fmt.Errorf("error finding CUE files: %w", err)
+		}
+		cueFiles = found
+	}
+
+	if len(cueFiles) == 0 {
+		log.Println("No CUE files found in current directory")
+		return nil
+	}
+
+	failed := 0
+	usedDirs := make(map[string]bool)
+	for i, cue := range cueFiles {
+		if !quiet {
+			fmt.Printf("\n[%d/%d] Embedding: %s\n", i+1, len(cueFiles), cue.Path)
+		}
+		if err := embedAlbum(cue, usedDirs); err != nil {
+			logFailure("Error embedding CUE sheet", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d album(s) failed", failed, len(cueFiles))
+	}
+	return nil
+}
+
+// embedAlbum parses one CUE sheet and writes its FLAC with the sheet embedded
+func embedAlbum(cue cueparser.CueFile, usedDirs map[string]bool) error {
+	if err := cueparser.Parse(&cue); err != nil {
+		return err
+	}
+
+	flacPath, err := resolveAudioFile(cue)
+	if err != nil {
+		return err
+	}
+
+	albumDir, err := createOutputDirectory(cue, embedOutputDir, usedDirs)
+	if err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	opts := flacsplitter.DefaultOptions(albumDir)
+	opts.EmbedTags = embedTags
+	opts.OverwriteFiles = overwrite
+	if err := flacsplitter.EmbedCue(cue, flacPath, opts); err != nil {
+		removeEmptyDirs(albumDir, embedOutputDir)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+	"github.com/ldmonster/flac-splitter/internal/flacsplitter"
+)
+
+// hookValues returns the token values available to --exec templates
+func hookValues(cue cueparser.CueFile, track cueparser.Track, path string) map[string]string {
+	values := layoutValues(cue)
+	values["file"] = path
+	values["tracknum"] = fmt.Sprintf("%02d", track.Number)
+	values["title"] = track.Title
+	values["artist"] = cmp.Or(track.Performer, values["artist"])
+	return values
+}
+
+// execHook returns a track hook running the --exec command template. The
+// template is split into arguments like a shell would, honoring quotes, but
+// is not run by one; tokens are expanded within each argument, so file names
+// with spaces stay a single argument.
+func execHook(template string) (flacsplitter.TrackHookFunc, error) {
+	args, err := splitCommand(template)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("--exec command is empty")
+	}
+	values := hookValues(cueparser.CueFile{}, cueparser.Track{}, "")
+	for _, match := range layoutToken.FindAllStringSubmatch(template, -1) {
+		if _, ok := values[match[1]]; !ok {
+			return nil, fmt.Errorf("unknown --exec token {%s}", match[1])
+		}
+	}
+
+	return func(cue cueparser.CueFile, track cueparser.Track, path string) error {
+		values := hookValues(cue, track, path)
+		expanded := make([]string, len(args))
+		for i, arg := range args {
+			expanded[i] = layoutToken.ReplaceAllStringFunc(arg, func(token string) string {
+				return values[token[1:len(token)-1]]
+			})
+		}
+
+		cmd := exec.Command(expanded[0], expanded[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w", expanded[0], err)
+		}
+		return nil
+	}, nil
+}
+
+// splitCommand splits a command line into arguments at unquoted whitespace.
+// Single quotes keep everything literally; inside double quotes a backslash
+// escapes the next character.
+func splitCommand(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else if c == '\\' && i+1 < len(s) {
+				i++
+				current.WriteByte(s[i])
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %s quote in %s", strconv.QuoteRune(rune(quote)), s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+	"github.com/ldmonster/flac-splitter/internal/flacsplitter"
+)
+
+// defaultLayout mirrors the source tree and names the album folder after the CUE file
+const defaultLayout = "{reldir}/{cuename}"
+
+// layoutToken matches {name} placeholders in a layout template
+var layoutToken = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// layoutValues returns the token values available to layout templates.
+// {reldir} is a path and is expanded without sanitization.
+func layoutValues(cue cueparser.CueFile) map[string]string {
+	cueName := strings.TrimSuffix(cue.FileName, filepath.Ext(cue.FileName))
+
+	return map[string]string{
+		"reldir":      filepath.Dir(cue.RelativePath),
+		"srcdir":      cmp.Or(sourceDirName(cue.Path), cueName),
+		"cuename":     cueName,
+		"album":       cmp.Or(cue.Album, cueName),
+		"albumartist": cmp.Or(flacsplitter.AlbumArtist(cue, variousArts), "Unknown Artist"),
+		"artist":      cmp.Or(cue.Performer, "Unknown Artist"),
+		"year":        cmp.Or(cue.Year, "Unknown"),
+		"date":        cmp.Or(cue.Date, "Unknown"),
+		"genre":       cmp.Or(cue.Genre, "Unknown"),
+		"disc":        cmp.Or(cue.DiscNumber, "1"),
+	}
+}
+
+// sourceDirName returns the name of the folder holding the CUE file at
+// cuePath, resolving relative paths, or "" at a filesystem root
+func sourceDirName(cuePath string) string {
+	if cuePath == "" {
+		return ""
+	}
+	dir, err := filepath.Abs(filepath.Dir(cuePath))
+	if err != nil {
+		return ""
+	}
+	if name := filepath.Base(dir); name != string(filepath.Separator) && name != "." {
+		return name
+	}
+	return ""
+}
+
+// validateLayout rejects templates with unknown tokens
+func validateLayout(layout string) error {
+	values := layoutValues(cueparser.CueFile{})
+	for _, match := range layoutToken.FindAllStringSubmatch(layout, -1) {
+		if _, ok := values[match[1]]; !ok {
+			return fmt.Errorf("unknown layout token {%s}", match[1])
+		}
+	}
+	return nil
+}
+
+// renderLayout renders a layout template into a relative directory path,
+// sanitizing every metadata-derived path component
+func renderLayout(layout string, cue cueparser.CueFile) string {
+	values := layoutValues(cue)
+
+	var parts []string
+	for _, segment := range strings.Split(layout, "/") {
+		if segment == "{reldir}" {
+			parts = append(parts, values["reldir"])
+			continue
+		}
+
+		rendered := layoutToken.ReplaceAllStringFunc(segment, func(token string) string {
+			return values[strings.Trim(token, "{}")]
+		})
+		if name := filenameRules.Sanitize(rendered); name != "" {
+			parts = append(parts, name)
+		}
+	}
+
+	return filepath.Join(parts...)
+}
+
+// uniqueDir returns dir, or dir with a numeric suffix when another album
+// already claimed dir/sub during this run
+func uniqueDir(dir, sub string, used map[string]bool) string {
+	candidate := dir
+	for n := 2; used[filepath.Join(candidate, sub)]; n++ {
+		candidate = fmt.Sprintf("%s (%d)", dir, n)
+	}
+	used[filepath.Join(candidate, sub)] = true
+	return candidate
+}
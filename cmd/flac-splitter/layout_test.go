@@ -0,0 +1,56 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+func TestRenderLayoutAlbumArtist(t *testing.T) {
+	compilation := cueparser.CueFile{
+		Performer: "Label",
+		Album:     "Hits",
+		Tracks: []cueparser.Track{
+			{Number: 1, Performer: "Label"},
+			{Number: 2, Performer: "Someone Else"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		cue            cueparser.CueFile
+		variousArtists bool
+		want           string
+	}{
+		{"album performer", compilation, false, filepath.Join("Label", "Hits")},
+		{"various artists", compilation, true, filepath.Join("Various Artists", "Hits")},
+		{"single artist", cueparser.CueFile{Performer: "Band", Album: "Live"}, true, filepath.Join("Band", "Live")},
+		{"no performer", cueparser.CueFile{Album: "Live"}, false, filepath.Join("Unknown Artist", "Live")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(saved bool) { variousArts = saved }(variousArts)
+			variousArts = tt.variousArtists
+
+			if got := renderLayout("{albumartist}/{album}", tt.cue); got != tt.want {
+				t.Errorf("renderLayout() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
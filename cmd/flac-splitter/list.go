@@ -0,0 +1,137 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+	"github.com/ldmonster/flac-splitter/internal/flacsplitter"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list [path]",
+	Short: "Print what CUE sheets describe without splitting anything",
+	Long: `List parses every CUE sheet under path (default: the current directory), or
+the single CUE file given, and prints its album details and track list. No
+directories are created and no audio is read. Use --verbose to also show custom
+REM fields.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runList,
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	root := "."
+	if len(args) == 1 {
+		root = args[0]
+	}
+
+	var cueFiles []cueparser.CueFile
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		cue, err := singleCueFile(root)
+		if err != nil {
+			return err
+		}
+		cueFiles = append(cueFiles, cue)
+	} else {
+		found, err := cueparser.FindAllWithOptions(root, cueparser.DefaultFindOptions())
+		if err != nil {
+			return fmt.Errorf("error finding CUE files: %w", err)
+		}
+		cueFiles = found
+	}
+
+	if len(cueFiles) == 0 {
+		fmt.Println("No CUE files found")
+		return nil
+	}
+
+	for i, cue := range cueFiles {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(cue.Path)
+
+		config := cueparser.DefaultConfig()
+		config.CollectWarnings = true
+		if err := cueparser.ParseWithConfig(&cue, config); err != nil {
+			fmt.Printf("  Error parsing CUE file: %v\n", err)
+			continue
+		}
+		printCue(cue)
+	}
+
+	return nil
+}
+
+// printCue prints the album details and track list of a parsed CUE sheet
+func printCue(cue cueparser.CueFile) {
+	fmt.Printf("  Album:  %s\n", cue.Album)
+	fmt.Printf("  Artist: %s\n", cue.Performer)
+	if cue.Year != "" {
+		fmt.Printf("  Year:   %s\n", cue.Year)
+	}
+	fmt.Printf("  Audio:  %s\n", cue.AudioFile)
+	if verbose {
+		printCustomFields("  ", cue.CustomFields)
+	}
+
+	for _, warning := range cue.Warnings {
+		fmt.Printf("  Warning: %s\n", warning)
+	}
+	for _, warning := range cue.NumberingWarnings() {
+		fmt.Printf("  Warning: %s\n", warning)
+	}
+
+	if hidden := flacsplitter.HiddenTrackDuration(cue); hidden > 0 {
+		fmt.Printf("  Hidden: %s of audio before track %d's INDEX 01 (HTOA)\n",
+			flacsplitter.FormatDuration(hidden.Seconds()), cue.AudioTracks()[0].Number)
+	}
+
+	fmt.Printf("  Tracks: %d\n", len(cue.Tracks))
+	for _, track := range cue.Tracks {
+		line := fmt.Sprintf("    %02d. [%s] %s", track.Number, track.Index, track.Title)
+		if track.Performer != "" && track.Performer != cue.Performer {
+			line += " - " + track.Performer
+		}
+		if !track.IsAudio() {
+			line += fmt.Sprintf(" (data track, %s)", track.Type)
+		}
+		fmt.Println(line)
+		if verbose {
+			printCustomFields("        ", track.CustomFields)
+		}
+	}
+}
+
+// printCustomFields prints custom REM fields in key order
+func printCustomFields(indent string, fields map[string]string) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Printf("%sREM %s: %s\n", indent, key, fields[key])
+	}
+}
@@ -15,11 +15,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/ldmonster/flac-splitter/internal/cueparser"
 	"github.com/ldmonster/flac-splitter/internal/flacsplitter"
@@ -30,10 +36,76 @@ var (
 	// Global flags
 	externalMode bool
 	hybridMode   bool
+	chapterMode  bool
 	useFFmpeg    bool
 	outputDir    string
+	strictTime   bool
+	variousArts  bool
+	maxNameLen   int
+	replaceChar  string
+	replaceMaps  []string
+	stripInvalid bool
+	strictName   bool
+	readCDText   bool
+	readRipLog   bool
+	audioPath    string
+	showProgress bool
+	includeGlobs []string
+	excludeGlobs []string
+	followLinks  bool
+	since        string
+	gapless      bool
+	insertGaps   bool
+	hiddenTrack  string
+	gapMode      string
+	minTrackLen  time.Duration
+	shortTracks  string
+	normalize    string
+	normTarget   float64
+	blockSize    int
+	nonSubset    bool
+	paddingSize  int
+	trackJobs    int
+	decodeJobs   int
+	maxMemory    string
+	outputFormat string
+	trackSpec    string
+	discFolders  bool
+	discPrefix   bool
+	manifest     bool
+	renameDups   bool
+	playlist     bool
+	playlistExt  string
+	tracksCue    bool
+	coverFile    string
+	maxRetries   int
+	noSpaceCheck bool
+	layout       string
+	flatten      bool
+	toolTimeout  time.Duration
+	toolName     string
+	keepTemp     bool
+	fallback     bool
+	chunkLength  time.Duration
+	tagMaps      []string
+	customTags   bool
+	fingerprint  bool
+	execCmd      string
+	execFatal    bool
+	trackNumFmt  string
+	discNumFmt   string
+	vendor       string
+	setAlbum     string
+	setArtist    string
+	setGenre     string
+	setYear      string
+	configFile   string
+	overwrite    bool
 	quiet        bool
 	verbose      bool
+
+	// filenameRules is built from --replace-char, --replace and --strip-invalid
+	filenameRules flacsplitter.FilenameRules
 )
 
 const (
@@ -41,13 +113,14 @@ const (
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "flac-splitter [flags]",
+	Use:   "flac-splitter [flags] [cue-file | flac-file | --chunk DURATION flac-file]",
 	Short: "Split FLAC files based on CUE sheets with comprehensive metadata tagging",
 	Long: `FLAC Splitter - A powerful tool for splitting large FLAC audio files into individual tracks
 
 This tool recursively searches for CUE sheet files in the current directory and splits 
-associated FLAC files into individual tracks. It preserves all metadata including album 
-information, track titles, artists, and more using the go-flac library.
+associated FLAC files into individual tracks, or processes just the CUE or FLAC file given. 
+It preserves all metadata including album information, track titles, artists, and more 
+using the go-flac library.
 
 Features:
   • Automatic CUE file discovery
@@ -60,6 +133,7 @@ Modes:
   • Pure Go (default): Decode, split, and re-encode FLAC with pure Go libraries
   • Hybrid (--hybrid): Validate with Go, split with external tools (fast + safe)
   • External (--external): Use only external tools - shnsplit or ffmpeg
+  • Chapters (--chapters): Keep one FLAC per album with chapter markers
 
 Supported Tools:
   External modes can use either shnsplit or ffmpeg. Use --ffmpeg to prefer ffmpeg.`,
@@ -75,6 +149,21 @@ Supported Tools:
   # External mode with ffmpeg preference
   flac-splitter --external --ffmpeg
 
+  # Keep a single file per album with chapter markers
+  flac-splitter --chapters
+
+  # Process a single CUE file instead of searching the tree
+  flac-splitter "Artist/Album/album.cue"
+
+  # Cut a FLAC without a CUE sheet into 10 minute parts
+  flac-splitter --chunk 10m "Recordings/field.flac"
+
+  # Name album folders from metadata instead of the CUE filename
+  flac-splitter --layout "{albumartist}/{year} - {album}"
+
+  # Show what the CUE sheets describe without splitting anything
+  flac-splitter list Music/
+
   # Specify custom output directory
   flac-splitter --output /path/to/output
 
@@ -83,22 +172,188 @@ Supported Tools:
 
   # Verbose mode (detailed progress)
   flac-splitter --verbose`,
-	Run: runSplitter,
+	Version: flacsplitter.Version,
+	Args:    cobra.MaximumNArgs(1),
+	Run:     runSplitter,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(cmd); err != nil {
+			// A bad config file is not a usage mistake; main prints the error
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return err
+		}
+		return nil
+	},
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "",
+		"Read default flag values from this YAML file instead of the default locations")
 	rootCmd.Flags().BoolVar(&externalMode, "external", false,
 		"Use external tools only (shnsplit/ffmpeg) - fastest")
 	rootCmd.Flags().BoolVar(&hybridMode, "hybrid", false,
 		"Hybrid mode: Go validation + external splitting (fast + safe)")
+	rootCmd.Flags().BoolVar(&chapterMode, "chapters", false,
+		"Write one FLAC per album with CHAPTER tags instead of splitting")
 	rootCmd.Flags().BoolVar(&useFFmpeg, "ffmpeg", false,
 		"Prefer ffmpeg over shnsplit (for external/hybrid modes)")
+	rootCmd.Flags().StringVar(&toolName, "tool", "",
+		"Force an external splitter: shnsplit, ffmpeg or sox (for external/hybrid modes)")
+	rootCmd.Flags().BoolVar(&keepTemp, "keep-temp", false,
+		"Keep the temporary CUE file passed to shnsplit for debugging")
+	rootCmd.Flags().DurationVar(&toolTimeout, "timeout", 0,
+		"Kill each shnsplit/ffmpeg invocation after this long, e.g. 10m (0 = no limit)")
+	rootCmd.Flags().DurationVar(&chunkLength, "chunk", 0,
+		"Split the given FLAC file (no CUE needed) into parts of this length, e.g. 10m")
 	rootCmd.Flags().StringVarP(&outputDir, "output", "o", defaultOutputDir,
 		"Output directory for split files")
-	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false,
+	rootCmd.Flags().BoolVar(&overwrite, "overwrite", false,
+		"Replace existing output files (default: skip them, or ask in a terminal)")
+	rootCmd.Flags().StringVar(&layout, "layout", defaultLayout,
+		"Album folder layout under the output directory; tokens: {reldir} {srcdir} {cuename} "+
+			"{album} {albumartist} {artist} {year} {date} {genre} {disc}")
+	rootCmd.Flags().BoolVar(&flatten, "flatten", false,
+		"Write all tracks directly into the output directory instead of album folders")
+	rootCmd.Flags().StringArrayVar(&tagMaps, "tag-map", nil,
+		"Map a field to Vorbis comment names, e.g. comment=COMMENT,DESCRIPTION (repeatable)")
+	rootCmd.Flags().BoolVar(&customTags, "custom-tags", false,
+		"Write custom REM fields from the CUE (e.g. REM SOURCE) as tags")
+	rootCmd.Flags().BoolVar(&fingerprint, "fingerprint", false,
+		"Tag each track with its ACOUSTID_FINGERPRINT (needs fpcalc from Chromaprint)")
+	rootCmd.Flags().StringVar(&execCmd, "exec", "",
+		"Run a command on every finished track, e.g. \"mediainfo {file}\"; tokens: {file} {tracknum} "+
+			"{title} {artist} {album} {albumartist} {year} {date} {genre} {disc}")
+	rootCmd.Flags().BoolVar(&execFatal, "exec-fatal", false,
+		"Fail the album when the --exec command fails instead of logging a warning")
+	rootCmd.Flags().StringVar(&trackNumFmt, "track-number", string(flacsplitter.NumberPlain),
+		"TRACKNUMBER format: plain (3), padded (03) or slashed (3/12)")
+	rootCmd.Flags().StringVar(&discNumFmt, "disc-number", string(flacsplitter.NumberPlain),
+		"DISCNUMBER format: plain (1), padded (01) or slashed (1/2)")
+	rootCmd.Flags().StringVar(&vendor, "vendor", flacsplitter.DefaultVendorString(),
+		"Vorbis comment vendor string for tagged files (empty keeps the encoder's)")
+	rootCmd.Flags().StringVar(&setAlbum, "album", "",
+		"Override the album title of every CUE file")
+	rootCmd.Flags().StringVar(&setArtist, "artist", "",
+		"Override the album artist of every CUE file, and the artist of tracks that inherit it")
+	rootCmd.Flags().StringVar(&setGenre, "genre", "",
+		"Override the genre of every CUE file")
+	rootCmd.Flags().StringVar(&setYear, "year", "",
+		"Override the year (and date) of every CUE file")
+	rootCmd.Flags().BoolVar(&strictTime, "strict-timecodes", false,
+		"Fail on out-of-range or malformed CUE timecodes instead of normalizing them")
+	rootCmd.Flags().BoolVar(&variousArts, "various-artists", false,
+		"Tag ALBUMARTIST as \"Various Artists\" when track performers differ from the album")
+	rootCmd.Flags().StringArrayVar(&includeGlobs, "include", nil,
+		"Only process CUE files matching this glob (repeatable, supports **)")
+	rootCmd.Flags().StringArrayVar(&excludeGlobs, "exclude", nil,
+		"Skip files and directories matching this glob (repeatable, supports **)")
+	rootCmd.Flags().BoolVar(&followLinks, "follow-symlinks", false,
+		"Descend into symlinked directories when searching for CUE files")
+	rootCmd.Flags().StringVar(&since, "since", "",
+		"Only process CUE files modified within this duration (e.g. 24h), since a date, or \"last\"")
+	rootCmd.Flags().BoolVar(&strictName, "strict-filename", false,
+		"Only use the audio file named in the CUE (no fallback search)")
+	rootCmd.Flags().BoolVar(&readCDText, "cdtext", false,
+		"Fill in metadata missing from the CUE from its CDTEXTFILE")
+	rootCmd.Flags().BoolVar(&readRipLog, "read-log", false,
+		"Tag the ripper, drive, gap handling and AccurateRip results from an EAC/XLD log beside the CUE")
+	rootCmd.Flags().StringVar(&audioPath, "audio", "",
+		"Audio file to split, overriding the CUE's FILE entry (single CUE file only)")
+	rootCmd.Flags().IntVar(&maxNameLen, "max-filename-length", 255,
+		"Maximum output filename length in bytes (0 = unlimited)")
+	rootCmd.Flags().StringVar(&replaceChar, "replace-char", "_",
+		"Replacement for characters that are invalid in filenames, such as : / ?")
+	rootCmd.Flags().StringArrayVar(&replaceMaps, "replace", nil,
+		"Replace one character in filenames, e.g. \":= -\" or /=- (repeatable; wins over --replace-char)")
+	rootCmd.Flags().BoolVar(&stripInvalid, "strip-invalid", false,
+		"Remove characters that are invalid in filenames instead of replacing them")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
 		"Quiet mode - only show errors and summary")
-	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false,
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
 		"Verbose mode - show detailed processing information")
+	rootCmd.Flags().BoolVar(&gapless, "gapless", false,
+		"Keep audio before track 1 and verify tracks rejoin bit-identically (pure Go mode)")
+	rootCmd.Flags().BoolVar(&insertGaps, "insert-gaps", false,
+		"Insert the silence described by CUE PREGAP/POSTGAP commands (pure Go mode)")
+	rootCmd.Flags().BoolVar(&discFolders, "disc-folders", false,
+		"Put the tracks of numbered discs into a \"Disc N\" folder inside the album folder")
+	rootCmd.Flags().BoolVar(&discPrefix, "disc-prefix", false,
+		"Prefix track filenames with the disc number, e.g. \"1-03 - Title.flac\"")
+	rootCmd.Flags().BoolVar(&noSpaceCheck, "no-space-check", false,
+		"Skip the free space and write permission check before each album")
+	rootCmd.Flags().BoolVar(&fallback, "fallback", false,
+		"Retry albums pure Go mode cannot split with external tools")
+	rootCmd.Flags().IntVar(&maxRetries, "retries", 0,
+		"Retry an external tool this many times on transient I/O errors (external/hybrid)")
+	rootCmd.Flags().BoolVar(&manifest, "manifest", false,
+		"Write an <album>.sha256 manifest of the output files (check with sha256sum -c)")
+	rootCmd.Flags().BoolVar(&renameDups, "rename-duplicates", false,
+		"Append \" (2)\" etc. to tracks that would share a file name instead of failing")
+	rootCmd.Flags().BoolVar(&playlist, "playlist", false,
+		"Write an <album> playlist of the tracks with durations and titles")
+	rootCmd.Flags().StringVar(&coverFile, "extract-cover", "",
+		"Write the front cover to this file in the album folder (e.g. folder.jpg) instead of embedding it")
+	rootCmd.Flags().BoolVar(&tracksCue, "tracks-cue", false,
+		"Write an <album>.cue sheet with one FILE entry per split track for re-import")
+	rootCmd.Flags().StringVar(&playlistExt, "playlist-format", string(flacsplitter.PlaylistM3U8),
+		"Playlist extension: m3u8 or m3u (both are written as UTF-8)")
+	rootCmd.Flags().StringVar(&trackSpec, "tracks", "",
+		"Only split the given track numbers, e.g. 3-5,8,10-")
+	rootCmd.Flags().StringVar(&outputFormat, "format", string(flacsplitter.OutputFLAC),
+		"Output format for tracks: flac or wav (wav is untagged, pure Go mode)")
+	rootCmd.Flags().IntVar(&blockSize, "block-size", flacsplitter.DefaultBlockSize,
+		"FLAC block size in samples for encoded tracks, 16-65535 (pure Go mode)")
+	rootCmd.Flags().BoolVar(&nonSubset, "non-subset", false,
+		"Allow a --block-size outside the FLAC subset, which some players cannot decode")
+	rootCmd.Flags().IntVar(&paddingSize, "padding", flacsplitter.DefaultPaddingBytes,
+		"Bytes of PADDING to reserve after the tags of every FLAC track, for later retagging (0 = none)")
+	rootCmd.Flags().IntVar(&trackJobs, "track-jobs", 1,
+		"Encode up to this many tracks of an album at once (pure Go mode)")
+	rootCmd.Flags().IntVar(&decodeJobs, "decode-jobs", 1,
+		"Decode the source FLAC with up to this many goroutines, split at its seek points (pure Go mode)")
+	rootCmd.Flags().StringVar(&maxMemory, "max-memory", "",
+		"Refuse albums whose decoded audio needs more memory than this, e.g. 2G, 512M or auto (pure Go mode)")
+	rootCmd.Flags().StringVar(&normalize, "normalize", "off",
+		"Rescale each album before encoding: off, peak or loudness (changes the audio, pure Go mode)")
+	rootCmd.Flags().Float64Var(&normTarget, "normalize-target", 0,
+		"Normalization target in dBFS (peak) or LUFS (loudness); 0 = -1 dBFS or -18 LUFS")
+	rootCmd.Flags().StringVar(&gapMode, "gaps", "previous",
+		"Which track gets the audio between INDEX 00 and INDEX 01: previous, next or drop")
+	rootCmd.Flags().DurationVar(&minTrackLen, "min-track-duration", 0,
+		"Treat tracks shorter than this (e.g. 2s) as spurious, see --short-tracks (0 = keep all)")
+	rootCmd.Flags().StringVar(&shortTracks, "short-tracks", "skip",
+		"What to do with tracks below --min-track-duration: skip or merge (into the previous track)")
+	rootCmd.Flags().StringVar(&hiddenTrack, "hidden-track", "discard",
+		"Audio before track 1's INDEX 01: discard, track0 or prepend (pure Go mode)")
+	rootCmd.Flags().BoolVar(&showProgress, "progress", false,
+		"Show a progress bar while decoding and encoding (pure Go mode)")
+}
+
+// hiddenTrackModes maps --hidden-track values to splitter modes
+var hiddenTrackModes = map[string]flacsplitter.HiddenTrackMode{
+	"discard": flacsplitter.HiddenTrackDiscard,
+	"track0":  flacsplitter.HiddenTrackSeparate,
+	"prepend": flacsplitter.HiddenTrackPrepend,
+}
+
+// normalizeModes maps --normalize values to splitter modes
+var normalizeModes = map[string]flacsplitter.NormalizeMode{
+	"off":      flacsplitter.NormalizeOff,
+	"peak":     flacsplitter.NormalizePeak,
+	"loudness": flacsplitter.NormalizeLoudness,
+}
+
+// gapModes maps --gaps values to track boundary modes
+var gapModes = map[string]flacsplitter.BoundaryMode{
+	"previous": flacsplitter.BoundaryIndex01,
+	"next":     flacsplitter.BoundaryIndex00,
+	"drop":     flacsplitter.BoundaryDropGaps,
+}
+
+// shortTrackModes maps --short-tracks values to splitter modes
+var shortTrackModes = map[string]flacsplitter.ShortTrackMode{
+	"skip":  flacsplitter.ShortTrackSkip,
+	"merge": flacsplitter.ShortTrackMerge,
 }
 
 func main() {
@@ -120,8 +375,25 @@ func runSplitter(cmd *cobra.Command, args []string) {
 	if externalMode && hybridMode {
 		log.Fatal("Error: Cannot use both --external and --hybrid flags")
 	}
+	if chapterMode && (externalMode || hybridMode) {
+		log.Fatal("Error: Cannot combine --chapters with --external or --hybrid")
+	}
+	if chunkLength > 0 {
+		if chapterMode || externalMode || hybridMode {
+			log.Fatal("Error: --chunk only works in pure Go mode")
+		}
+		if len(args) != 1 {
+			log.Fatal("Error: --chunk requires a FLAC file argument")
+		}
+	}
+	if audioPath != "" && (len(args) != 1 || chunkLength > 0) {
+		log.Fatal("Error: --audio requires a single CUE file argument")
+	}
 
-	if externalMode {
+	if chapterMode {
+		mode = flacsplitter.ModeChapterize
+		modeDesc = "Chapters (single file with chapter markers)"
+	} else if externalMode {
 		mode = flacsplitter.ModeExternalTools
 		modeDesc = "External tools only (shnsplit/ffmpeg)"
 	} else if hybridMode {
@@ -136,16 +408,158 @@ func runSplitter(cmd *cobra.Command, args []string) {
 		log.Printf("Mode: %s", modeDesc)
 	}
 
-	// Step 1: Find all CUE files
-	if !quiet {
-		log.Println("Step 1: Finding all CUE files...")
+	if err := validateLayout(layout); err != nil {
+		log.Fatalf("Error: invalid --layout: %v", err)
+	}
+	if flatten && (cmd.Flags().Changed("layout") || discFolders) {
+		log.Fatal("Error: Cannot combine --flatten with --layout or --disc-folders")
 	}
-	cueFiles, err := cueparser.FindAll(".", outputDir)
+
+	hiddenMode, ok := hiddenTrackModes[hiddenTrack]
+	if !ok {
+		log.Fatalf("Error: invalid --hidden-track %q (want discard, track0 or prepend)", hiddenTrack)
+	}
+
+	normalizeMode, ok := normalizeModes[normalize]
+	if !ok {
+		log.Fatalf("Error: invalid --normalize %q (want off, peak or loudness)", normalize)
+	}
+
+	boundaryMode, ok := gapModes[gapMode]
+	if !ok {
+		log.Fatalf("Error: invalid --gaps %q (want previous, next or drop)", gapMode)
+	}
+
+	if setYear != "" && !yearPattern.MatchString(setYear) {
+		log.Fatalf("Error: invalid --year %q (want four digits)", setYear)
+	}
+
+	shortMode, ok := shortTrackModes[shortTracks]
+	if !ok {
+		log.Fatalf("Error: invalid --short-tracks %q (want skip or merge)", shortTracks)
+	}
+
+	if paddingSize < 0 {
+		log.Fatalf("Error: invalid --padding %d (want 0 or more bytes)", paddingSize)
+	}
+
+	maxDecode, err := parseMemoryLimit(maxMemory)
 	if err != nil {
-		log.Fatalf("Error finding CUE files: %v", err)
+		log.Fatalf("Error: invalid --max-memory: %v", err)
+	}
+
+	var tracks flacsplitter.TrackSelection
+	if trackSpec != "" {
+		var err error
+		if tracks, err = flacsplitter.ParseTrackSelection(trackSpec); err != nil {
+			log.Fatalf("Error: invalid --tracks: %v", err)
+		}
+	}
+
+	var trackHook flacsplitter.TrackHookFunc
+	if execCmd != "" {
+		var err error
+		if trackHook, err = execHook(execCmd); err != nil {
+			log.Fatalf("Error: invalid --exec: %v", err)
+		}
+	}
+
+	tagMapping := flacsplitter.DefaultTagMapping()
+	for _, entry := range tagMaps {
+		if err := tagMapping.Set(entry); err != nil {
+			log.Fatalf("Error: invalid --tag-map: %v", err)
+		}
+	}
+	if readRipLog {
+		// Rip log fields are written even without --custom-tags
+		for _, key := range cueparser.RipLogFields {
+			if _, mapped := tagMapping[flacsplitter.CustomFieldPrefix+key]; !mapped {
+				tagMapping[flacsplitter.CustomFieldPrefix+key] = []string{key}
+			}
+		}
+	}
+
+	filenameRules = flacsplitter.FilenameRules{Replacement: replaceChar, Strip: stripInvalid}
+	for _, entry := range replaceMaps {
+		char, replacement, ok := strings.Cut(entry, "=")
+		if strings.HasPrefix(entry, "==") {
+			char, replacement, ok = "=", entry[2:], true
+		}
+		if !ok || utf8.RuneCountInString(char) != 1 {
+			log.Fatalf("Error: invalid --replace %q (want CHAR=REPLACEMENT)", entry)
+		}
+		if filenameRules.Map == nil {
+			filenameRules.Map = make(map[rune]string)
+		}
+		r, _ := utf8.DecodeRuneInString(char)
+		filenameRules.Map[r] = replacement
+	}
+	if err := filenameRules.Validate(); err != nil {
+		log.Fatalf("Error: invalid --replace-char or --replace: %v", err)
+	}
+
+	// The run's start is what the next --since last run compares against, so
+	// CUE files changed while it runs are picked up next time
+	runStart := time.Now()
+	var modifiedSince time.Time
+	if since != "" {
+		var err error
+		if modifiedSince, err = parseSince(since, outputDir, runStart); err != nil {
+			log.Fatalf("Error: invalid --since: %v", err)
+		}
+		if verbose && !modifiedSince.IsZero() {
+			log.Printf("Only processing CUE files modified since %s", modifiedSince.Format(time.DateTime))
+		}
+	}
+
+	// Step 1: Find all CUE files (or use the one given on the command line).
+	// Sheets built from --chunk or read from a CUESHEET tag are already parsed.
+	var cueFiles []cueparser.CueFile
+	preparsed := false
+	if chunkLength > 0 {
+		cue, err := flacsplitter.ChunkedCue(args[0], chunkLength)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		cueFiles = append(cueFiles, cue)
+		preparsed = true
+	} else if len(args) == 1 && strings.EqualFold(filepath.Ext(args[0]), ".flac") {
+		cue, err := flacsplitter.TaggedCue(args[0], parserConfig())
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		logParseWarnings(cue)
+		cueFiles = append(cueFiles, cue)
+		preparsed = true
+	} else if len(args) == 1 {
+		cue, err := singleCueFile(args[0])
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		cueFiles = append(cueFiles, cue)
+	} else {
+		if !quiet {
+			log.Println("Step 1: Finding all CUE files...")
+		}
+		findOpts := cueparser.DefaultFindOptions()
+		findOpts.SkipDirs = []string{outputDir}
+		findOpts.Include = includeGlobs
+		findOpts.Exclude = excludeGlobs
+		findOpts.FollowSymlinks = followLinks
+		findOpts.ModifiedSince = modifiedSince
+
+		found, err := cueparser.FindAllWithOptions(".", findOpts)
+		if err != nil {
+			log.Fatalf("Error finding CUE files: %v", err)
+		}
+		cueFiles = found
 	}
 
 	if len(cueFiles) == 0 {
+		if !modifiedSince.IsZero() {
+			log.Printf("No CUE files modified since %s found in current directory", modifiedSince.Format(time.DateTime))
+			return
+		}
 		log.Println("No CUE files found in current directory")
 		return
 	}
@@ -170,6 +584,13 @@ func runSplitter(cmd *cobra.Command, args []string) {
 	successCount := 0
 	failureCount := 0
 	skippedCount := 0
+	exitCode := 0
+	usedDirs := make(map[string]bool)
+	var prompt *overwritePrompt
+	if !overwrite {
+		prompt = newOverwritePrompt()
+	}
+	claimedFiles := make(map[string]string) // output file -> CUE that writes it, with --flatten
 
 	for i, cue := range cueFiles {
 		if !quiet {
@@ -177,26 +598,44 @@ func runSplitter(cmd *cobra.Command, args []string) {
 		}
 
 		// Parse CUE file
-		if err := cueparser.Parse(&cue); err != nil {
-			log.Printf("  ✗ Error parsing CUE file: %v", err)
-			failureCount++
-			continue
+		if !preparsed {
+			if err := cueparser.ParseWithConfig(&cue, parserConfig()); err != nil {
+				logFailure("Error parsing CUE file", err)
+				exitCode = max(exitCode, exitCodeFor(err))
+				failureCount++
+				continue
+			}
+			logParseWarnings(cue)
+		}
+		applyOverrides(&cue)
+		if hidden := flacsplitter.HiddenTrackDuration(cue); hidden > 0 && verbose {
+			log.Printf("  Hidden track one audio: %s before track %d (see --hidden-track)",
+				flacsplitter.FormatDuration(hidden.Seconds()), cue.AudioTracks()[0].Number)
 		}
 
 		// Check if FLAC file exists
-		flacPath := cue.GetAudioFilePath()
-		if _, err := os.Stat(flacPath); os.IsNotExist(err) {
+		flacPath, err := resolveAudioFile(cue)
+		if errors.Is(err, cueparser.ErrAudioNotFound) {
 			if verbose || !quiet {
-				log.Printf("  ⊘ Skipped: FLAC file not found: %s", flacPath)
+				log.Printf("  ⊘ Skipped: %v", err)
 			}
 			skippedCount++
 			continue
 		}
+		if err != nil {
+			logFailure("Error locating audio file", err)
+			exitCode = max(exitCode, exitCodeFor(err))
+			failureCount++
+			continue
+		}
 
-		// Create output directory structure
-		trackOutputDir, err := createOutputDirectory(cue, outputDir)
+		// Create output directory structure only once the CUE parsed and its
+		// audio was found, so layouts can use album metadata and skipped
+		// albums leave no empty folders behind
+		trackOutputDir, err := createOutputDirectory(cue, outputDir, usedDirs)
 		if err != nil {
-			log.Printf("  ✗ Error creating output directory: %v", err)
+			logFailure("Error creating output directory", err)
+			exitCode = max(exitCode, exitFailed)
 			failureCount++
 			continue
 		}
@@ -211,9 +650,83 @@ func runSplitter(cmd *cobra.Command, args []string) {
 		opts := flacsplitter.DefaultOptions(trackOutputDir)
 		opts.Mode = mode
 		opts.UseFFmpeg = useFFmpeg
+		opts.ExternalTimeout = toolTimeout
+		opts.Tool = toolName
+		opts.KeepTempFiles = keepTemp
+		opts.FallbackToExternal = fallback
+		opts.MaxRetries = maxRetries
+		opts.SkipSpaceCheck = noSpaceCheck
+		opts.StrictTimecodes = strictTime
+		opts.VariousArtists = variousArts
+		opts.MaxFilenameLength = maxNameLen
+		opts.FilenameRules = filenameRules
+		opts.Gapless = gapless
+		if insertGaps {
+			opts.PregapMode = flacsplitter.PregapInsertSilence
+		}
+		opts.HiddenTrack = hiddenMode
+		opts.BoundaryMode = boundaryMode
+		opts.MinTrackDuration = minTrackLen
+		opts.ShortTracks = shortMode
+		opts.Normalize = normalizeMode
+		opts.NormalizeTarget = normTarget
+		opts.BlockSize = blockSize
+		opts.NonSubset = nonSubset
+		opts.PaddingBytes = paddingSize
+		opts.TrackConcurrency = trackJobs
+		opts.DecodeConcurrency = decodeJobs
+		opts.MaxDecodeBytes = maxDecode
+		opts.OutputFormat = flacsplitter.OutputFormat(outputFormat)
+		opts.Tracks = tracks
+		opts.DiscSubfolder = discFolders
+		opts.WriteManifest = manifest
+		opts.RenameDuplicates = renameDups
+		opts.WritePlaylist = playlist
+		opts.PlaylistFormat = flacsplitter.PlaylistFormat(playlistExt)
+		opts.WriteTracksCue = tracksCue
+		opts.ExtractCoverTo = coverFile
+		if discPrefix {
+			opts.FilenamePattern = "{disc}-" + opts.FilenamePattern
+		}
+		opts.TagMapping = tagMapping
+		opts.WriteCustomFields = customTags
+		opts.Fingerprint = fingerprint
+		opts.Verbose = verbose
+		opts.TrackHook = trackHook
+		opts.TrackHookFatal = execFatal
+		opts.TrackNumberFormat = flacsplitter.NumberFormat(trackNumFmt)
+		opts.DiscNumberFormat = flacsplitter.NumberFormat(discNumFmt)
+		opts.VendorString = vendor
+		opts.OverwriteFiles = overwrite
+		if prompt != nil {
+			if existing := existingFiles(flacsplitter.PlannedOutputs(cue, flacPath, opts)); len(existing) > 0 {
+				opts.OverwriteFiles = prompt.allow(existing)
+			}
+		}
 
-		if err := flacsplitter.Split(cue, flacPath, opts); err != nil {
-			log.Printf("  ✗ Error splitting FLAC file: %v", err)
+		if flatten {
+			if err := claimOutputs(cue, flacPath, opts, claimedFiles); err != nil {
+				log.Printf("  ✗ Error: %v", err)
+				exitCode = max(exitCode, exitFailed)
+				failureCount++
+				continue
+			}
+		}
+
+		var bar *progressBar
+		if showProgress {
+			bar = newProgressBar(os.Stderr)
+			opts.Progress = bar.Update
+		}
+
+		err = flacsplitter.Split(cue, flacPath, opts)
+		if bar != nil {
+			bar.Finish()
+		}
+		if err != nil {
+			logFailure("Error splitting FLAC file", err)
+			exitCode = max(exitCode, exitCodeFor(err))
+			removeEmptyDirs(filepath.Join(trackOutputDir, discFolder(cue)), outputDir)
 			failureCount++
 			continue
 		}
@@ -236,19 +749,203 @@ func runSplitter(cmd *cobra.Command, args []string) {
 	}
 	fmt.Printf("\nOutput directory: %s\n", outputDir)
 
-	if failureCount > 0 {
-		os.Exit(1)
+	// Failed albums are retried by the next --since last run
+	if strings.EqualFold(since, sinceLast) && failureCount == 0 {
+		if err := saveLastRun(outputDir, runStart); err != nil {
+			log.Printf("Warning: Failed to record the run time for --since last: %v", err)
+		}
+	}
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// Exit codes; when albums fail for different reasons the highest is used
+const (
+	exitFailed     = 1 // an album could not be split
+	exitParseError = 2 // a CUE sheet is malformed or invalid
+	exitNoSplitter = 3 // no usable external splitter is installed
+)
+
+// exitCodeFor returns the exit code for an album that failed with err
+func exitCodeFor(err error) int {
+	var parseErr *cueparser.ParseError
+	var validationErr *cueparser.ValidationError
+	switch {
+	case errors.Is(err, flacsplitter.ErrNoSplitter):
+		return exitNoSplitter
+	case errors.As(err, &parseErr), errors.As(err, &validationErr):
+		return exitParseError
+	default:
+		return exitFailed
+	}
+}
+
+// logFailure logs why an album failed, with a hint where one helps
+func logFailure(what string, err error) {
+	var validationErr *cueparser.ValidationError
+	if errors.As(err, &validationErr) {
+		log.Printf("  ✗ %s: the CUE sheet is invalid:", what)
+		for _, problem := range validationErr.Problems {
+			log.Printf("    - %s", problem)
+		}
+		return
+	}
+
+	log.Printf("  ✗ %s: %v", what, err)
+	if errors.Is(err, flacsplitter.ErrNoSplitter) {
+		log.Printf("    Install shntool, ffmpeg or sox, or drop --external/--hybrid to split in pure Go mode")
+	}
+	if errors.Is(err, flacsplitter.ErrDuplicateOutput) {
+		log.Printf("    Use --rename-duplicates to number the clashing tracks")
+	}
+}
+
+// resolveAudioFile locates the audio file for a CUE: the --audio file if
+// given, otherwise the FILE entry, searching the CUE's directory when that
+// is wrong unless --strict-filename is set
+func resolveAudioFile(cue cueparser.CueFile) (string, error) {
+	if audioPath != "" {
+		if _, err := os.Stat(audioPath); err != nil {
+			return "", fmt.Errorf("cannot access --audio file: %w", err)
+		}
+		if verbose {
+			log.Printf("  Using %s (--audio) instead of FILE entry %q", audioPath, cue.AudioFile)
+		}
+		return audioPath, nil
+	}
+	if strictName {
+		flacPath := cue.GetAudioFilePath()
+		if _, err := os.Stat(flacPath); os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: %s", cueparser.ErrAudioNotFound, flacPath)
+		}
+		return flacPath, nil
+	}
+
+	flacPath, err := cue.FindAudioFile()
+	if err != nil {
+		return "", err
+	}
+	if flacPath != cue.GetAudioFilePath() && !quiet {
+		log.Printf("  Using %s (FILE entry %q not found)", flacPath, cue.AudioFile)
+	}
+	return flacPath, nil
+}
+
+// sizePattern matches the sizes accepted by --max-memory
+var sizePattern = regexp.MustCompile(`^(\d+)([KMGT]?)B?$`)
+
+// sizeShifts maps size suffixes to their power of 1024
+var sizeShifts = map[string]uint{"": 0, "K": 10, "M": 20, "G": 30, "T": 40}
+
+// parseMemoryLimit parses --max-memory: empty for no limit, a size with an
+// optional K, M, G or T suffix (powers of 1024), or "auto" for half of the
+// memory currently available
+func parseMemoryLimit(value string) (uint64, error) {
+	switch strings.ToLower(value) {
+	case "", "0":
+		return 0, nil
+	case "auto":
+		available, err := flacsplitter.AvailableMemory()
+		if err != nil {
+			return 0, fmt.Errorf("auto: %w", err)
+		}
+		return available / 2, nil
 	}
+
+	match := sizePattern.FindStringSubmatch(strings.ToUpper(value))
+	if match == nil {
+		return 0, fmt.Errorf("%q is not a size (want e.g. 2G, 512M or auto)", value)
+	}
+	size, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q: %w", value, err)
+	}
+	shift := sizeShifts[match[2]]
+	if size > math.MaxUint64>>shift {
+		return 0, fmt.Errorf("%q is too large", value)
+	}
+	return size << shift, nil
 }
 
-// createOutputDirectory creates the output directory structure
-func createOutputDirectory(cue cueparser.CueFile, baseOutputDir string) (string, error) {
-	// Get the parent directory of the CUE file (relative to current dir)
-	relDir := filepath.Dir(cue.RelativePath)
+// parserConfig returns the CUE parser configuration selected by the flags
+func parserConfig() *cueparser.ParserConfig {
+	config := cueparser.DefaultConfig()
+	config.CollectWarnings = !quiet
+	config.ReadCDText = readCDText
+	config.ReadRipLog = readRipLog
+	return config
+}
+
+// logParseWarnings logs the warnings collected while parsing cue
+func logParseWarnings(cue cueparser.CueFile) {
+	if quiet {
+		return
+	}
+	for _, warning := range cue.Warnings {
+		log.Printf("  Warning: %s", warning)
+	}
+	for _, warning := range cue.NumberingWarnings() {
+		log.Printf("  Warning: %s", warning)
+	}
+}
 
-	// Create base path: baseOutputDir/RelativeDir/CueName
-	cueName := strings.TrimSuffix(cue.FileName, filepath.Ext(cue.FileName))
-	trackOutputDir := filepath.Join(baseOutputDir, relDir, cueName)
+// yearPattern matches the years accepted by --year
+var yearPattern = regexp.MustCompile(`^\d{4}$`)
+
+// applyOverrides replaces the CUE metadata given by --album, --artist,
+// --genre and --year. Track performers are replaced only where they match
+// the album performer, so the track artists of compilations survive.
+func applyOverrides(cue *cueparser.CueFile) {
+	if setAlbum != "" {
+		cue.Album = setAlbum
+	}
+	if setArtist != "" {
+		for i := range cue.Tracks {
+			if cue.Tracks[i].Performer == "" || cue.Tracks[i].Performer == cue.Performer {
+				cue.Tracks[i].Performer = setArtist
+			}
+		}
+		cue.Performer = setArtist
+	}
+	if setGenre != "" {
+		cue.Genre = setGenre
+	}
+	if setYear != "" {
+		cue.Year = setYear
+		cue.Date = setYear
+	}
+}
+
+// singleCueFile builds a CueFile for a path given on the command line
+func singleCueFile(path string) (cueparser.CueFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return cueparser.CueFile{}, fmt.Errorf("cannot access CUE file: %w", err)
+	}
+	if info.IsDir() || strings.ToLower(filepath.Ext(path)) != ".cue" {
+		return cueparser.CueFile{}, fmt.Errorf("not a CUE file: %s", path)
+	}
+
+	// The relative path only feeds the output layout, so a single file always
+	// lands directly under the output directory
+	return cueparser.CueFile{
+		Path:         path,
+		RelativePath: info.Name(),
+		FileName:     info.Name(),
+	}, nil
+}
+
+// createOutputDirectory creates the album output directory from the --layout
+// template, adding a numeric suffix when two albums render to the same path.
+// With --disc-folders the discs of a set share the album directory, and with
+// --flatten every album writes straight into the base directory.
+func createOutputDirectory(cue cueparser.CueFile, baseOutputDir string, usedDirs map[string]bool) (string, error) {
+	if flatten {
+		return baseOutputDir, nil
+	}
+	trackOutputDir := uniqueDir(filepath.Join(baseOutputDir, renderLayout(layout, cue)), discFolder(cue), usedDirs)
 
 	if err := os.MkdirAll(trackOutputDir, 0755); err != nil {
 		return "", err
@@ -256,3 +953,39 @@ func createOutputDirectory(cue cueparser.CueFile, baseOutputDir string) (string,
 
 	return trackOutputDir, nil
 }
+
+// claimOutputs records the files an album will write, failing when one of them
+// is already claimed by an earlier album so flattened output never overwrites
+// another album's tracks
+func claimOutputs(cue cueparser.CueFile, flacPath string, opts *flacsplitter.SplitOptions, claimed map[string]string) error {
+	paths := flacsplitter.PlannedOutputs(cue, flacPath, opts)
+	for _, path := range paths {
+		if owner, ok := claimed[path]; ok {
+			return fmt.Errorf("%s would overwrite a file written for %s", path, owner)
+		}
+	}
+	for _, path := range paths {
+		claimed[path] = cue.Path
+	}
+	return nil
+}
+
+// discFolder returns the disc subfolder Split adds with --disc-folders
+func discFolder(cue cueparser.CueFile) string {
+	if !discFolders {
+		return ""
+	}
+	return flacsplitter.DiscFolderName(cue)
+}
+
+// removeEmptyDirs removes dir and its parents up to (but excluding) stopAt
+// as long as they are empty
+func removeEmptyDirs(dir, stopAt string) {
+	stopAt = filepath.Clean(stopAt)
+	for dir = filepath.Clean(dir); dir != stopAt && dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		// os.Remove refuses to delete non-empty directories
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestParseMemoryLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"1048576", 1 << 20, false},
+		{"512M", 512 << 20, false},
+		{"2g", 2 << 30, false},
+		{"2GB", 2 << 30, false},
+		{"16777215T", 16777215 << 40, false},
+		{"16777216T", 0, true},
+		{"18446744073709551615", 1<<64 - 1, false},
+		{"18446744073709551615K", 0, true},
+		{"18446744073709551616", 0, true},
+		{"17179869184G", 0, true},
+		{"2.5G", 0, true},
+		{"-1G", 0, true},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMemoryLimit(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseMemoryLimit(%q) = %d, %v, want %d, error %t", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ldmonster/flac-splitter/internal/flacsplitter"
+	"github.com/spf13/cobra"
+)
+
+var mergeOutput string
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [dir]",
+	Short: "Join split FLAC tracks back into one FLAC with a CUE sheet",
+	Long: `Merge is the inverse of splitting: it decodes the FLAC files in dir (default:
+the current directory) in filename order, concatenates their audio into one
+FLAC file and writes a CUE sheet next to it whose track offsets are the
+cumulative track lengths. Titles, artists and album details come from each
+track's Vorbis comments. The output is named after the folder unless --output
+is given.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMerge,
+}
+
+func init() {
+	mergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "",
+		"FLAC file to write (default: <folder>.flac in the current directory)")
+	mergeCmd.Flags().BoolVar(&overwrite, "overwrite", false,
+		"Replace existing output files")
+	mergeCmd.Flags().IntVar(&blockSize, "block-size", flacsplitter.DefaultBlockSize,
+		"Encoder block size in samples")
+	mergeCmd.Flags().BoolVar(&nonSubset, "non-subset", false,
+		"Allow a --block-size outside the FLAC subset")
+	rootCmd.AddCommand(mergeCmd)
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	output := mergeOutput
+	if output == "" {
+		output = flacsplitter.SanitizeFilename(filepath.Base(absDir)) + ".flac"
+	}
+	absOutput, err := filepath.Abs(output)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var tracks []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".flac") ||
+			filepath.Join(absDir, entry.Name()) == absOutput {
+			continue
+		}
+		tracks = append(tracks, path)
+	}
+	sort.Strings(tracks)
+	if len(tracks) == 0 {
+		return fmt.Errorf("no FLAC files found in %s", dir)
+	}
+
+	if !quiet {
+		fmt.Printf("Merging %d tracks from %s\n", len(tracks), dir)
+	}
+	opts := flacsplitter.DefaultOptions(filepath.Dir(output))
+	opts.OverwriteFiles = overwrite
+	opts.BlockSize = blockSize
+	opts.NonSubset = nonSubset
+	if _, err := flacsplitter.MergeTracks(tracks, output, opts); err != nil {
+		return fmt.Errorf("error merging tracks: %w", err)
+	}
+	return nil
+}
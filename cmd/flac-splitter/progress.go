@@ -0,0 +1,71 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const progressBarWidth = 40
+
+// progressBar renders a single-line ANSI progress bar
+type progressBar struct {
+	mu      sync.Mutex
+	out     io.Writer
+	percent int
+	active  bool
+}
+
+// newProgressBar creates a progress bar writing to out
+func newProgressBar(out io.Writer) *progressBar {
+	return &progressBar{out: out, percent: -1}
+}
+
+// Update redraws the bar when the whole percentage changes; safe for
+// concurrent use so it can be passed as a flacsplitter.ProgressFunc
+func (p *progressBar) Update(current, total uint64) {
+	if total == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	percent := int(current * 100 / total)
+	if percent == p.percent {
+		return
+	}
+	p.percent = percent
+	p.active = true
+
+	filled := percent * progressBarWidth / 100
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	fmt.Fprintf(p.out, "\r\033[K  [%s] %3d%%", bar, percent)
+}
+
+// Finish ends the progress line so following output starts on a new line
+func (p *progressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.active {
+		fmt.Fprintln(p.out)
+	}
+	p.percent = -1
+	p.active = false
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// overwritePrompt asks on the terminal whether an album may replace output
+// files left by an earlier run
+type overwritePrompt struct {
+	in  *bufio.Reader
+	all bool // "all" was answered, stop asking
+}
+
+// newOverwritePrompt returns a prompt reading answers from stdin, or nil when
+// stdin is not a terminal and nobody could answer
+func newOverwritePrompt() *overwritePrompt {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &overwritePrompt{in: bufio.NewReader(os.Stdin)}
+}
+
+// allow lists the existing files and reports whether they may be overwritten.
+// Anything but yes or all, including end of input, keeps them.
+func (p *overwritePrompt) allow(existing []string) bool {
+	if p.all {
+		return true
+	}
+
+	fmt.Printf("  %d output file(s) already exist, e.g. %s\n", len(existing), existing[0])
+	fmt.Print("  Overwrite them? [y]es, [N]o, [a]ll albums: ")
+	answer, _ := p.in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "a", "all":
+		p.all = true
+		return true
+	default:
+		return false
+	}
+}
+
+// existingFiles returns the paths that already exist
+func existingFiles(paths []string) []string {
+	var existing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	return existing
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lastRunFile records, in the output directory, when the last --since last
+// run started
+const lastRunFile = ".flac-splitter-last-run"
+
+// sinceLast is the --since value that reads the time from lastRunFile
+const sinceLast = "last"
+
+// sinceLayouts are the timestamps accepted by --since besides durations
+var sinceLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// parseSince parses --since: a duration before now (e.g. 36h), a timestamp
+// in local time unless it carries a zone, or "last" for the start of the
+// last --since last run into outputDir. A first "last" run returns the zero
+// time, so every CUE file is processed.
+func parseSince(value, outputDir string, now time.Time) (time.Time, error) {
+	if strings.EqualFold(value, sinceLast) {
+		data, err := os.ReadFile(filepath.Join(outputDir, lastRunFile))
+		if errors.Is(err, os.ErrNotExist) {
+			return time.Time{}, nil
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s: %w", lastRunFile, err)
+		}
+		return t, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("%q is negative", value)
+		}
+		return now.Add(-d), nil
+	}
+	for _, layout := range sinceLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a duration, a date or %q", value, sinceLast)
+}
+
+// saveLastRun records start as the time of the last run for --since last
+func saveLastRun(outputDir string, start time.Time) error {
+	path := filepath.Join(outputDir, lastRunFile)
+	return os.WriteFile(path, []byte(start.Format(time.RFC3339Nano)+"\n"), 0644)
+}
@@ -0,0 +1,230 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cueparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// CD-TEXT pack types
+const (
+	packTitle      = 0x80
+	packPerformer  = 0x81
+	packSongwriter = 0x82
+	packComposer   = 0x83
+	packArranger   = 0x84
+	packMessage    = 0x85
+	packDiscID     = 0x86
+	packGenre      = 0x87
+	packCode       = 0x8E // UPC/EAN for the disc, ISRC for tracks
+	packSizeInfo   = 0x8F
+)
+
+// cdTextPackSize is the size of a pack: 4 header bytes, 12 bytes of payload
+// and a 2-byte CRC
+const cdTextPackSize = 18
+
+// ErrInvalidCDText is returned for data that is not a CD-TEXT pack stream
+var ErrInvalidCDText = errors.New("invalid CD-TEXT data")
+
+// CDTextEntry holds the CD-TEXT strings of the disc or of one track
+type CDTextEntry struct {
+	Title      string
+	Performer  string
+	Songwriter string
+	Composer   string
+	Arranger   string
+	Message    string
+	Code       string // UPC/EAN for the disc, ISRC for a track
+}
+
+// CDText is the first language block of a binary CD-TEXT file
+type CDText struct {
+	Disc   CDTextEntry
+	Tracks map[int]CDTextEntry // by track number
+	DiscID string
+	Genre  string
+}
+
+// cdTextGenres names the CD-TEXT genre codes
+var cdTextGenres = [...]string{
+	3: "Adult Contemporary", 4: "Alternative Rock", 5: "Childrens", 6: "Classical",
+	7: "Contemporary Christian", 8: "Country", 9: "Dance", 10: "Easy Listening",
+	11: "Erotic", 12: "Folk", 13: "Gospel", 14: "Hip Hop", 15: "Jazz", 16: "Latin",
+	17: "Musical", 18: "New Age", 19: "Opera", 20: "Operetta", 21: "Pop", 22: "Rap",
+	23: "Reggae", 24: "Rock", 25: "Rhythm & Blues", 26: "Sound Effects",
+	27: "Spoken Word", 28: "World Music",
+}
+
+// ReadCDText reads and decodes a binary CD-TEXT file such as disc.cdt
+func ReadCDText(path string) (*CDText, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CD-TEXT file: %w", err)
+	}
+	return ParseCDText(data)
+}
+
+// ParseCDText decodes raw CD-TEXT packs, optionally preceded by the 4-byte
+// length header cdrecord writes. Only the first language block is read, and
+// double-byte (MS-JIS) text is not supported.
+func ParseCDText(data []byte) (*CDText, error) {
+	switch len(data) % cdTextPackSize {
+	case 0:
+	case 4:
+		data = data[4:]
+	case 5:
+		// Header plus a terminating NUL
+		data = data[4 : len(data)-1]
+	default:
+		return nil, fmt.Errorf("%w: %d bytes is not a whole number of packs", ErrInvalidCDText, len(data))
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: no packs", ErrInvalidCDText)
+	}
+
+	// Collect the payload of every pack type in block 0, together with the
+	// track its first pack starts at
+	payloads := make(map[byte][]byte)
+	firstTrack := make(map[byte]int)
+	for off := 0; off < len(data); off += cdTextPackSize {
+		pack := data[off : off+cdTextPackSize]
+		kind := pack[0]
+		if kind < packTitle || kind > packSizeInfo {
+			return nil, fmt.Errorf("%w: unknown pack type 0x%02X", ErrInvalidCDText, kind)
+		}
+		if block := pack[3] >> 4 & 0x07; block != 0 {
+			continue
+		}
+		if pack[3]&0x80 != 0 && kind != packSizeInfo {
+			return nil, fmt.Errorf("%w: double-byte text is not supported", ErrInvalidCDText)
+		}
+		if _, seen := payloads[kind]; !seen {
+			firstTrack[kind] = int(pack[1] & 0x7F)
+		}
+		payloads[kind] = append(payloads[kind], pack[4:16]...)
+	}
+
+	text := &CDText{Tracks: make(map[int]CDTextEntry)}
+	fields := []struct {
+		kind  byte
+		field func(entry *CDTextEntry) *string
+	}{
+		{packTitle, func(e *CDTextEntry) *string { return &e.Title }},
+		{packPerformer, func(e *CDTextEntry) *string { return &e.Performer }},
+		{packSongwriter, func(e *CDTextEntry) *string { return &e.Songwriter }},
+		{packComposer, func(e *CDTextEntry) *string { return &e.Composer }},
+		{packArranger, func(e *CDTextEntry) *string { return &e.Arranger }},
+		{packMessage, func(e *CDTextEntry) *string { return &e.Message }},
+		{packCode, func(e *CDTextEntry) *string { return &e.Code }},
+	}
+	for _, f := range fields {
+		for track, value := range cdTextStrings(payloads[f.kind], firstTrack[f.kind]) {
+			if track == 0 {
+				*f.field(&text.Disc) = value
+				continue
+			}
+			entry := text.Tracks[track]
+			*f.field(&entry) = value
+			text.Tracks[track] = entry
+		}
+	}
+
+	text.DiscID = cdTextStrings(payloads[packDiscID], 0)[0]
+	if genre := payloads[packGenre]; len(genre) >= 2 {
+		// A 2-byte genre code, then optional free text
+		code := int(genre[0])<<8 | int(genre[1])
+		text.Genre = cdTextStrings(genre[2:], 0)[0]
+		if text.Genre == "" && code < len(cdTextGenres) {
+			text.Genre = cdTextGenres[code]
+		}
+	}
+
+	return text, nil
+}
+
+// cdTextStrings splits the payload of one pack type into its NUL-terminated
+// strings, which belong to consecutive tracks from first on. A lone tab
+// repeats the previous track's string.
+func cdTextStrings(payload []byte, first int) map[int]string {
+	values := make(map[int]string)
+	previous := ""
+	for i, raw := range bytes.Split(payload, []byte{0}) {
+		value := latin1(raw)
+		if value == "\t" {
+			value = previous
+		}
+		if value != "" {
+			values[first+i] = value
+		}
+		previous = value
+	}
+	return values
+}
+
+// latin1 decodes ISO 8859-1 text, which CD-TEXT uses for single-byte blocks
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// ApplyCDText fills metadata the CUE sheet left empty from CD-TEXT. Tracks
+// without their own PERFORMER inherit the album's when the CUE is parsed, so
+// a track performer equal to the album's is replaced by a CD-TEXT one.
+func (c *CueFile) ApplyCDText(text *CDText) {
+	if text == nil {
+		return
+	}
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fillCustom := func(fields map[string]string, key, value string) {
+		if fields != nil && value != "" && fields[key] == "" {
+			fields[key] = value
+		}
+	}
+
+	albumPerformer := c.Performer
+	fill(&c.Album, text.Disc.Title)
+	fill(&c.Performer, text.Disc.Performer)
+	fill(&c.Songwriter, text.Disc.Songwriter)
+	fill(&c.Composer, text.Disc.Composer)
+	fill(&c.Comment, text.Disc.Message)
+	fill(&c.Catalog, text.Disc.Code)
+	fill(&c.Genre, text.Genre)
+	fillCustom(c.CustomFields, "ARRANGER", text.Disc.Arranger)
+
+	for i := range c.Tracks {
+		track := &c.Tracks[i]
+		entry := text.Tracks[track.Number]
+		fill(&track.Title, entry.Title)
+		if entry.Performer != "" && track.Performer == albumPerformer {
+			track.Performer = entry.Performer
+		}
+		fill(&track.Performer, c.Performer)
+		fill(&track.Songwriter, entry.Songwriter)
+		fill(&track.Composer, entry.Composer)
+		fill(&track.ISRC, entry.Code)
+		fillCustom(track.CustomFields, "ARRANGER", entry.Arranger)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cueparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAudioNotFound is returned when the audio file of a CUE sheet cannot be located
+var ErrAudioNotFound = errors.New("audio file not found")
+
+// ParseError reports a CUE sheet line that could not be parsed
+type ParseError struct {
+	Path string // CUE file path, empty when parsing a reader without one
+	Line int    // 1-based line number
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every problem Validate found in a CUE sheet
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cueparser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeTree creates empty files at the given slash-separated paths below root
+func writeTree(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// foundPaths returns the slash-separated relative paths of the found files
+func foundPaths(cues []CueFile) []string {
+	var paths []string
+	for _, cue := range cues {
+		paths = append(paths, filepath.ToSlash(cue.RelativePath))
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func TestFindAllSkipDirs(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root,
+		"split/skipped.cue",
+		"splitting/kept.cue",
+		"band-splits/kept.cue",
+		"music/split/skipped.cue",
+		"a/skipped.cue",
+		"abc/kept.cue",
+		"b/a.cue",
+		"out/deep/skipped.cue",
+		"deep/kept.cue",
+	)
+
+	cues, err := FindAll(root, "split", "a", filepath.Join(root, "out", "deep"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"abc/kept.cue",
+		"b/a.cue",
+		"band-splits/kept.cue",
+		"deep/kept.cue",
+		"splitting/kept.cue",
+	}
+	if got := foundPaths(cues); !slices.Equal(got, want) {
+		t.Errorf("FindAll() = %q, want %q", got, want)
+	}
+}
+
+func TestFindAllSkipDirsRelativePath(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "out/split/skipped.cue", "split/kept.cue")
+	t.Chdir(root)
+
+	cues, err := FindAll(".", filepath.Join("out", "split"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"split/kept.cue"}
+	if got := foundPaths(cues); !slices.Equal(got, want) {
+		t.Errorf("FindAll() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package cueparser
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// dirID identifies a directory independently of the path it was reached by
+type dirID struct {
+	path string
+}
+
+// dirIdentity returns the directory at path with all links resolved, as
+// os.FileInfo carries no file index on Windows
+func dirIdentity(path string, _ os.FileInfo) (dirID, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return dirID{}, false
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return dirID{}, false
+	}
+	return dirID{path: resolved}, true
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cueparser
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+)
+
+// DefaultIgnoreFile is the gitignore-style file read from the search root
+const DefaultIgnoreFile = ".flacignore"
+
+// ignoreRule is a single pattern from an ignore file
+type ignoreRule struct {
+	pattern  string
+	negate   bool // "!pattern" re-includes a previously ignored path
+	dirOnly  bool // "pattern/" only matches directories
+	anchored bool // pattern contains a slash and matches from the root
+}
+
+// loadIgnoreFile reads gitignore-style rules; a missing file yields no rules
+func loadIgnoreFile(filename string) ([]ignoreRule, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// ignored reports whether relPath is ignored; the last matching rule wins
+func ignored(rules []ignoreRule, relPath string, isDir bool) bool {
+	result := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchPattern(rule.pattern, relPath, rule.anchored) {
+			result = !rule.negate
+		}
+	}
+	return result
+}
+
+// matchPattern matches a glob against a slash-separated relative path.
+// Anchored patterns match the whole path, others match the base name.
+func matchPattern(pattern, relPath string, anchored bool) bool {
+	if anchored {
+		return matchGlob(pattern, relPath)
+	}
+	return matchGlob(pattern, path.Base(relPath))
+}
+
+// matchGlob matches a slash-separated path against a glob where "**"
+// matches zero or more whole path components
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches path components against pattern components
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse consecutive "**" and try every possible split point
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchesAny reports whether relPath matches one of the globs; globs
+// without a slash are matched against the base name
+func matchesAny(globs []string, relPath string) bool {
+	for _, glob := range globs {
+		if matchPattern(glob, relPath, strings.Contains(glob, "/")) {
+			return true
+		}
+	}
+	return false
+}
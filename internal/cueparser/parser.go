@@ -16,11 +16,18 @@ package cueparser
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
 )
 
 // CueFile represents a CUE file with its metadata
@@ -33,7 +40,12 @@ type CueFile struct {
 	AudioFile     string // Main audio file (FLAC, WAV, etc.)
 	AudioFileType string // WAVE, MP3, FLAC, etc.
 
-	// Album metadata
+	// CDTextFile is the CDTEXTFILE path as written in the CUE (see ReadCDText)
+	CDTextFile string
+
+	// Album metadata. The first TITLE and PERFORMER before any TRACK win;
+	// later album-level ones (e.g. a separate disc title) are kept in
+	// CustomFields as TITLE2, TITLE3, ... and PERFORMER2, PERFORMER3, ...
 	Album      string
 	Performer  string
 	Composer   string
@@ -51,16 +63,24 @@ type CueFile struct {
 	DiscNumber string
 	TotalDiscs string
 
+	// ReplayGain values as written in the CUE (e.g. "-7.50 dB")
+	ReplayGainAlbumGain string
+	ReplayGainAlbumPeak string
+
 	// Tracks
 	Tracks []Track
 
 	// Custom fields for any other metadata
 	CustomFields map[string]string
+
+	// Warnings lists suspicious lines when ParserConfig.CollectWarnings is set
+	Warnings []ParseWarning
 }
 
 // Track represents a single track in a CUE file
 type Track struct {
-	Number     int
+	Number     int    // Track number as written in the CUE sheet
+	Type       string // Track datatype, e.g. AUDIO or MODE1/2352
 	Title      string
 	Performer  string
 	Composer   string
@@ -69,6 +89,14 @@ type Track struct {
 	Index      string // Main index (01)
 	PreGap     string // Index 00 if exists
 
+	// PREGAP/POSTGAP commands: lengths of silence that is not in the audio file
+	PregapSilence  string
+	PostgapSilence string
+
+	// ReplayGain values from REM lines inside the TRACK block
+	ReplayGainTrackGain string
+	ReplayGainTrackPeak string
+
 	// Custom fields for track-specific metadata
 	CustomFields map[string]string
 }
@@ -83,6 +111,21 @@ type ParserConfig struct {
 
 	// ParseCustomREM enables parsing of custom REM fields
 	ParseCustomREM bool
+
+	// CollectWarnings records lines that look like directives but could not
+	// be parsed, and TRACK lines reusing a track number, in CueFile.Warnings
+	// instead of silently accepting them
+	CollectWarnings bool
+
+	// ReadCDText decodes the binary CD-TEXT file named by CDTEXTFILE, if any,
+	// and fills in metadata the CUE sheet lacks. A relative CDTEXTFILE is
+	// resolved against the directory of CueFile.Path.
+	ReadCDText bool
+
+	// ReadRipLog reads the EAC or XLD log beside CueFile.Path, if any (see
+	// FindRipLog), and stores the ripper, drive, gap handling and
+	// AccurateRip results as custom fields (see ApplyRipLog)
+	ReadRipLog bool
 }
 
 // DefaultConfig returns a default parser configuration
@@ -97,6 +140,7 @@ func DefaultConfig() *ParserConfig {
 // patterns holds compiled regex patterns for parsing CUE files
 type patterns struct {
 	file       *regexp.Regexp
+	cdTextFile *regexp.Regexp
 	performer  *regexp.Regexp
 	title      *regexp.Regexp
 	composer   *regexp.Regexp
@@ -104,6 +148,8 @@ type patterns struct {
 	track      *regexp.Regexp
 	index      *regexp.Regexp
 	pregap     *regexp.Regexp
+	pregapCmd  *regexp.Regexp
+	postgapCmd *regexp.Regexp
 	isrc       *regexp.Regexp
 	catalog    *regexp.Regexp
 
@@ -114,6 +160,7 @@ type patterns struct {
 	remComment    *regexp.Regexp
 	remDiscID     *regexp.Regexp
 	remDiscNumber *regexp.Regexp
+	remReplayGain *regexp.Regexp
 	remCustom     *regexp.Regexp
 }
 
@@ -121,13 +168,16 @@ type patterns struct {
 func initPatterns() *patterns {
 	return &patterns{
 		file:       regexp.MustCompile(`FILE\s+"([^"]+)"\s+(\w+)`),
+		cdTextFile: regexp.MustCompile(`^\s*CDTEXTFILE\s+(?:"([^"]+)"|(\S+))`),
 		performer:  regexp.MustCompile(`^\s*PERFORMER\s+"([^"]+)"`),
 		title:      regexp.MustCompile(`^\s*TITLE\s+"([^"]+)"`),
 		composer:   regexp.MustCompile(`^\s*COMPOSER\s+"([^"]+)"`),
 		songwriter: regexp.MustCompile(`^\s*SONGWRITER\s+"([^"]+)"`),
-		track:      regexp.MustCompile(`^\s*TRACK\s+(\d+)\s+AUDIO`),
-		index:      regexp.MustCompile(`^\s*INDEX\s+01\s+(\d+:\d+:\d+)`),
-		pregap:     regexp.MustCompile(`^\s*INDEX\s+00\s+(\d+:\d+:\d+)`),
+		track:      regexp.MustCompile(`^\s*TRACK\s+(\d+)\s+(\S+)`),
+		index:      regexp.MustCompile(`^\s*INDEX\s+01\s+(\d+:\d+(?::\d+:\d+|:\d+|\.\d+))`),
+		pregap:     regexp.MustCompile(`^\s*INDEX\s+00\s+(\d+:\d+(?::\d+:\d+|:\d+|\.\d+))`),
+		pregapCmd:  regexp.MustCompile(`^\s*PREGAP\s+(\d+:\d+(?::\d+:\d+|:\d+|\.\d+))`),
+		postgapCmd: regexp.MustCompile(`^\s*POSTGAP\s+(\d+:\d+(?::\d+:\d+|:\d+|\.\d+))`),
 		isrc:       regexp.MustCompile(`^\s*ISRC\s+([A-Z0-9]+)`),
 		catalog:    regexp.MustCompile(`^\s*CATALOG\s+(\d+)`),
 
@@ -137,53 +187,165 @@ func initPatterns() *patterns {
 		remComment:    regexp.MustCompile(`^\s*REM\s+COMMENT\s+(.+)$`),
 		remDiscID:     regexp.MustCompile(`^\s*REM\s+DISCID\s+([A-Fa-f0-9]+)`),
 		remDiscNumber: regexp.MustCompile(`^\s*REM\s+DISC(?:NUMBER)?\s+(\d+)(?:/(\d+))?`),
+		remReplayGain: regexp.MustCompile(`^\s*REM\s+REPLAYGAIN_(ALBUM|TRACK)_(GAIN|PEAK)\s+(.+)$`),
 		remCustom:     regexp.MustCompile(`^\s*REM\s+([A-Z_][A-Z0-9_]*)\s+(.+)$`),
 	}
 }
 
+// FindOptions configures CUE file discovery
+type FindOptions struct {
+	// SkipDirs lists directory names that are never descended into
+	SkipDirs []string
+
+	// Include limits results to CUE files matching one of these globs
+	Include []string
+
+	// Exclude skips files and directories matching any of these globs
+	Exclude []string
+
+	// IgnoreFile is a gitignore-style file read from the root (empty disables)
+	IgnoreFile string
+
+	// FollowSymlinks descends into symlinked directories, reporting their CUE
+	// files below the link's path. Each directory is searched once, however
+	// many links lead to it, so link cycles end.
+	FollowSymlinks bool
+
+	// ModifiedSince, if not zero, skips CUE files last modified before it
+	ModifiedSince time.Time
+}
+
+// DefaultFindOptions returns default discovery options
+func DefaultFindOptions() *FindOptions {
+	return &FindOptions{
+		IgnoreFile: DefaultIgnoreFile,
+	}
+}
+
 // FindAll recursively finds all .cue files in the given directory
 func FindAll(rootPath string, skipDirs ...string) ([]CueFile, error) {
+	opts := DefaultFindOptions()
+	opts.SkipDirs = skipDirs
+	return FindAllWithOptions(rootPath, opts)
+}
+
+// FindAllWithOptions recursively finds .cue files using custom options
+func FindAllWithOptions(rootPath string, opts *FindOptions) ([]CueFile, error) {
 	var cueFiles []CueFile
-	skipMap := make(map[string]bool)
-	for _, dir := range skipDirs {
-		skipMap[dir] = true
+
+	// Bare names match any path component; every entry also matches its own
+	// cleaned absolute path, so "out/split" or "/abs/split" work as well
+	skipNames := make(map[string]bool)
+	skipPaths := make(map[string]bool)
+	for _, dir := range opts.SkipDirs {
+		dir = filepath.Clean(dir)
+		if !strings.ContainsRune(dir, os.PathSeparator) && dir != "." && dir != ".." {
+			skipNames[dir] = true
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			skipPaths[abs] = true
+		}
 	}
 
-	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+	var rules []ignoreRule
+	if opts.IgnoreFile != "" {
+		loaded, err := loadIgnoreFile(filepath.Join(rootPath, opts.IgnoreFile))
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("failed to read %s: %w", opts.IgnoreFile, err)
 		}
+		rules = loaded
+	}
+
+	visited := make(map[dirID]bool)
+
+	// walkFn visits the tree at realRoot as if it were at shownRoot, which
+	// differ only below a followed symlink
+	var walkFn func(realRoot, shownRoot string) filepath.WalkFunc
+	walkFn = func(realRoot, shownRoot string) filepath.WalkFunc {
+		return func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if realPath := path; realRoot != shownRoot {
+				path = shownRoot + strings.TrimPrefix(realPath, realRoot)
+			}
+
+			// A directory reached again through a link is searched only once
+			if opts.FollowSymlinks && info.IsDir() {
+				if id, ok := dirIdentity(path, info); ok {
+					if visited[id] {
+						return filepath.SkipDir
+					}
+					visited[id] = true
+				}
+			}
 
-		// Skip directories in skipMap
-		for skipDir := range skipMap {
-			if strings.Contains(path, skipDir) {
+			relPath, _ := filepath.Rel(rootPath, path)
+			if relPath == "." {
+				return nil
+			}
+			slashPath := filepath.ToSlash(relPath)
+
+			skip := func() error {
 				if info.IsDir() {
 					return filepath.SkipDir
 				}
 				return nil
 			}
-		}
 
-		// Skip hidden directories and .dist folder (but not the root path)
-		if info.IsDir() && path != rootPath && path != "." {
-			if strings.HasPrefix(info.Name(), ".") || info.Name() == ".dist" {
-				return filepath.SkipDir
+			// Skip configured directories, matching whole path components only
+			for _, component := range strings.Split(relPath, string(os.PathSeparator)) {
+				if skipNames[component] {
+					return skip()
+				}
+			}
+			if info.IsDir() {
+				if abs, err := filepath.Abs(path); err == nil && skipPaths[abs] {
+					return filepath.SkipDir
+				}
 			}
-		}
 
-		// Check if it's a CUE file
-		if !info.IsDir() && strings.ToLower(filepath.Ext(path)) == ".cue" {
-			relPath, _ := filepath.Rel(rootPath, path)
-			cueFiles = append(cueFiles, CueFile{
-				Path:         path,
-				RelativePath: relPath,
-				FileName:     info.Name(),
-			})
-		}
+			// Skip hidden directories and .dist folder (but not the root path)
+			if info.IsDir() {
+				if name := filepath.Base(path); strings.HasPrefix(name, ".") || name == ".dist" {
+					return filepath.SkipDir
+				}
+			}
 
-		return nil
-	})
+			// Skip paths matched by exclude globs or the ignore file
+			if matchesAny(opts.Exclude, slashPath) || ignored(rules, slashPath, info.IsDir()) {
+				return skip()
+			}
 
+			// filepath.Walk reports symlinks without following them
+			if opts.FollowSymlinks && info.Mode()&os.ModeSymlink != 0 {
+				if target, err := filepath.EvalSymlinks(path); err == nil {
+					if targetInfo, err := os.Stat(target); err == nil && targetInfo.IsDir() {
+						return filepath.Walk(target, walkFn(target, path))
+					}
+				}
+			}
+
+			// Check if it's a CUE file
+			if !info.IsDir() && strings.ToLower(filepath.Ext(path)) == ".cue" {
+				if len(opts.Include) > 0 && !matchesAny(opts.Include, slashPath) {
+					return nil
+				}
+				if info.ModTime().Before(opts.ModifiedSince) {
+					return nil
+				}
+				cueFiles = append(cueFiles, CueFile{
+					Path:         path,
+					RelativePath: relPath,
+					FileName:     info.Name(),
+				})
+			}
+
+			return nil
+		}
+	}
+
+	err := filepath.Walk(rootPath, walkFn(rootPath, rootPath))
 	return cueFiles, err
 }
 
@@ -200,18 +362,28 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 	}
 	defer file.Close()
 
+	return ParseReader(cue, file, config)
+}
+
+// ParseReader parses CUE sheet contents from r into cue. Lines may end in
+// \n, \r\n or a lone \r, and the last line needs no line ending. Input
+// starting with a byte order mark is decoded as UTF-8, UTF-16LE or UTF-16BE
+// (see DecodeBOM).
+func ParseReader(cue *CueFile, r io.Reader, config *ParserConfig) error {
 	// Initialize custom fields maps
 	if cue.CustomFields == nil {
 		cue.CustomFields = make(map[string]string)
 	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(DecodeBOM(r))
+	scanner.Split(ScanLines)
 	pat := initPatterns()
 
 	var currentTrack *Track
-	albumSet := false
+	albumTitles, albumPerformers := 0, 0
 	albumPerformer := ""
-	lineNum := 0
+	lineNum, cdTextLine := 0, 0
+	trackLines := make(map[int]int) // line of each track number's TRACK
 
 	for scanner.Scan() {
 		lineNum++
@@ -226,6 +398,13 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 			continue
 		}
 
+		// Parse CDTEXTFILE
+		if matches := pat.cdTextFile.FindStringSubmatch(line); matches != nil {
+			cue.CDTextFile = matches[1] + matches[2]
+			cdTextLine = lineNum
+			continue
+		}
+
 		// Parse CATALOG
 		if matches := pat.catalog.FindStringSubmatch(line); matches != nil {
 			cue.Catalog = matches[1]
@@ -234,8 +413,13 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 
 		// Parse REM fields
 		if strings.HasPrefix(strings.TrimSpace(line), "REM") {
-			if err := parseREMField(line, cue, config, pat); err != nil && config.StrictMode {
-				return fmt.Errorf("line %d: %w", lineNum, err)
+			if err := parseREMField(line, cue, currentTrack, config, pat); err != nil {
+				if config.StrictMode {
+					return &ParseError{Path: cue.Path, Line: lineNum, Err: err}
+				}
+				if config.CollectWarnings {
+					cue.Warnings = append(cue.Warnings, ParseWarning{Line: lineNum, Text: line, Message: err.Error()})
+				}
 			}
 			continue
 		}
@@ -246,6 +430,10 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 				if albumPerformer == "" {
 					albumPerformer = matches[1]
 					cue.Performer = matches[1]
+					albumPerformers = 1
+				} else {
+					albumPerformers++
+					cue.CustomFields[fmt.Sprintf("PERFORMER%d", albumPerformers)] = matches[1]
 				}
 			} else {
 				currentTrack.Performer = matches[1]
@@ -275,11 +463,15 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 
 		// Parse TITLE (album or track title)
 		if matches := pat.title.FindStringSubmatch(line); matches != nil {
-			if !albumSet && currentTrack == nil {
-				cue.Album = matches[1]
-				albumSet = true
-			} else if currentTrack != nil {
+			if currentTrack != nil {
 				currentTrack.Title = matches[1]
+			} else {
+				albumTitles++
+				if albumTitles == 1 {
+					cue.Album = matches[1]
+				} else {
+					cue.CustomFields[fmt.Sprintf("TITLE%d", albumTitles)] = matches[1]
+				}
 			}
 			continue
 		}
@@ -291,9 +483,25 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 				fillTrackDefaults(currentTrack, albumPerformer)
 				cue.Tracks = append(cue.Tracks, *currentTrack)
 			}
-			// Create new track
+			// Create new track, keeping the CUE's own numbering
+			number, err := strconv.Atoi(matches[1])
+			if err != nil {
+				return &ParseError{Path: cue.Path, Line: lineNum, Err: fmt.Errorf("invalid track number %q", matches[1])}
+			}
+			if first, ok := trackLines[number]; ok {
+				err := fmt.Errorf("track number %d is already used on line %d", number, first)
+				if config.StrictMode {
+					return &ParseError{Path: cue.Path, Line: lineNum, Err: err}
+				}
+				if config.CollectWarnings {
+					cue.Warnings = append(cue.Warnings, ParseWarning{Line: lineNum, Text: line, Message: err.Error()})
+				}
+			} else {
+				trackLines[number] = lineNum
+			}
 			currentTrack = &Track{
-				Number:       len(cue.Tracks) + 1,
+				Number:       number,
+				Type:         strings.ToUpper(matches[2]),
 				CustomFields: make(map[string]string),
 			}
 			continue
@@ -313,12 +521,28 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 				continue
 			}
 
+			// PREGAP / POSTGAP (silence not present in the file)
+			if matches := pat.pregapCmd.FindStringSubmatch(line); matches != nil {
+				currentTrack.PregapSilence = matches[1]
+				continue
+			}
+			if matches := pat.postgapCmd.FindStringSubmatch(line); matches != nil {
+				currentTrack.PostgapSilence = matches[1]
+				continue
+			}
+
 			// ISRC
 			if matches := pat.isrc.FindStringSubmatch(line); matches != nil {
 				currentTrack.ISRC = matches[1]
 				continue
 			}
 		}
+
+		if config.CollectWarnings {
+			if message := unmatchedDirective(line, currentTrack != nil); message != "" {
+				cue.Warnings = append(cue.Warnings, ParseWarning{Line: lineNum, Text: line, Message: message})
+			}
+		}
 	}
 
 	// Add the last track
@@ -331,6 +555,28 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 		return fmt.Errorf("error reading CUE file: %w", err)
 	}
 
+	if config.ReadCDText && cue.CDTextFile != "" {
+		text, err := ReadCDText(cue.GetCDTextFilePath())
+		switch {
+		case err == nil:
+			cue.ApplyCDText(text)
+		case config.StrictMode:
+			return &ParseError{Path: cue.Path, Line: cdTextLine, Err: err}
+		case config.CollectWarnings:
+			cue.Warnings = append(cue.Warnings, ParseWarning{Line: cdTextLine, Text: "CDTEXTFILE " + cue.CDTextFile, Message: err.Error()})
+		}
+	}
+
+	if config.ReadRipLog && cue.Path != "" {
+		if logPath := FindRipLog(cue.Path); logPath != "" {
+			ripLog, err := ReadRipLog(logPath)
+			if err != nil && config.StrictMode {
+				return fmt.Errorf("%s: %w", logPath, err)
+			}
+			cue.ApplyRipLog(ripLog)
+		}
+	}
+
 	// Validate parsed data
 	if config.StrictMode {
 		if err := cue.Validate(); err != nil {
@@ -341,10 +587,67 @@ func ParseWithConfig(cue *CueFile, config *ParserConfig) error {
 	return nil
 }
 
-// parseREMField parses REM (remark) fields
-func parseREMField(line string, cue *CueFile, config *ParserConfig, pat *patterns) error {
+// DecodeBOM returns a reader of r converted to UTF-8 when it starts with a
+// UTF-8, UTF-16LE or UTF-16BE byte order mark, which is dropped. Input
+// without one is passed through unchanged, whatever its encoding.
+func DecodeBOM(r io.Reader) io.Reader {
+	return transform.NewReader(r, unicode.BOMOverride(transform.Nop))
+}
+
+// ScanLines is a bufio.SplitFunc like bufio.ScanLines that also treats a
+// lone \r as a line ending, as written by classic Mac OS tools
+func ScanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// A \r needs the following byte to tell \r\n from a lone \r
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+
+	// Final line without a line ending
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// parseREMField parses REM (remark) fields; track is the enclosing TRACK
+// block, or nil for album-level remarks
+func parseREMField(line string, cue *CueFile, track *Track, config *ParserConfig, pat *patterns) error {
 	line = strings.TrimSpace(line)
 
+	// REPLAYGAIN_ALBUM_* / REPLAYGAIN_TRACK_*
+	if matches := pat.remReplayGain.FindStringSubmatch(line); matches != nil {
+		value := strings.TrimSpace(matches[3])
+		switch {
+		case matches[1] == "ALBUM" && matches[2] == "GAIN":
+			cue.ReplayGainAlbumGain = value
+		case matches[1] == "ALBUM" && matches[2] == "PEAK":
+			cue.ReplayGainAlbumPeak = value
+		case track == nil:
+			return fmt.Errorf("REPLAYGAIN_TRACK_%s outside of a TRACK block", matches[2])
+		case matches[2] == "GAIN":
+			track.ReplayGainTrackGain = value
+		default:
+			track.ReplayGainTrackPeak = value
+		}
+		return nil
+	}
+
 	// DATE
 	if matches := pat.remDate.FindStringSubmatch(line); matches != nil {
 		cue.Date = matches[1]
@@ -366,13 +669,13 @@ func parseREMField(line string, cue *CueFile, config *ParserConfig, pat *pattern
 
 	// GENRE
 	if matches := pat.remGenre.FindStringSubmatch(line); matches != nil {
-		cue.Genre = strings.TrimSpace(matches[1])
+		cue.Genre = unquote(strings.TrimSpace(matches[1]))
 		return nil
 	}
 
 	// COMMENT
 	if matches := pat.remComment.FindStringSubmatch(line); matches != nil {
-		cue.Comment = strings.TrimSpace(matches[1])
+		cue.Comment = unquote(strings.TrimSpace(matches[1]))
 		return nil
 	}
 
@@ -401,10 +704,17 @@ func parseREMField(line string, cue *CueFile, config *ParserConfig, pat *pattern
 			knownFields := map[string]bool{
 				"DATE": true, "YEAR": true, "GENRE": true, "COMMENT": true,
 				"DISCID": true, "DISCNUMBER": true, "DISC": true,
+				"REPLAYGAIN_ALBUM_GAIN": true, "REPLAYGAIN_ALBUM_PEAK": true,
+				"REPLAYGAIN_TRACK_GAIN": true, "REPLAYGAIN_TRACK_PEAK": true,
 			}
 
+			// Remarks inside a TRACK block belong to that track
 			if !knownFields[key] {
-				cue.CustomFields[key] = value
+				if track != nil {
+					track.CustomFields[key] = value
+				} else {
+					cue.CustomFields[key] = value
+				}
 			}
 		}
 	}
@@ -412,6 +722,15 @@ func parseREMField(line string, cue *CueFile, config *ParserConfig, pat *pattern
 	return nil
 }
 
+// unquote strips one pair of surrounding double quotes, so REM values such as
+// `"ExactAudioCopy v1.3"` lose their quotes while unquoted ones stay verbatim
+func unquote(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
 // fillTrackDefaults sets default values for track fields
 func fillTrackDefaults(track *Track, albumPerformer string) {
 	if track.Performer == "" {
@@ -419,30 +738,61 @@ func fillTrackDefaults(track *Track, albumPerformer string) {
 	}
 }
 
-// Validate checks if the CueFile has required fields
+// Validate checks if the CueFile has required fields, returning a
+// *ValidationError that lists every problem found
 func (c *CueFile) Validate() error {
+	var problems []string
 	if c.AudioFile == "" {
-		return fmt.Errorf("missing FILE directive")
+		problems = append(problems, "missing FILE directive")
 	}
 	if c.Album == "" {
-		return fmt.Errorf("missing album TITLE")
+		problems = append(problems, "missing album TITLE")
 	}
 	if len(c.Tracks) == 0 {
-		return fmt.Errorf("no tracks found")
+		problems = append(problems, "no tracks found")
 	}
 
-	for i, track := range c.Tracks {
+	seen := make(map[int]bool)
+	for _, track := range c.Tracks {
+		if seen[track.Number] {
+			problems = append(problems, fmt.Sprintf("duplicate track number %d", track.Number))
+		}
+		seen[track.Number] = true
+
+		if !track.IsAudio() {
+			continue
+		}
 		if track.Title == "" {
-			return fmt.Errorf("track %d missing TITLE", i+1)
+			problems = append(problems, fmt.Sprintf("track %d missing TITLE", track.Number))
 		}
 		if track.Index == "" {
-			return fmt.Errorf("track %d missing INDEX 01", i+1)
+			problems = append(problems, fmt.Sprintf("track %d missing INDEX 01", track.Number))
 		}
 	}
 
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
 	return nil
 }
 
+// NumberingWarnings describes out-of-order and skipped track numbers, which
+// usually point at a hand-edited or merged CUE sheet. Duplicate numbers are
+// reported while parsing, with their lines (see ParserConfig.CollectWarnings).
+func (c *CueFile) NumberingWarnings() []string {
+	var warnings []string
+	for i := 1; i < len(c.Tracks); i++ {
+		prev, cur := c.Tracks[i-1].Number, c.Tracks[i].Number
+		switch {
+		case cur < prev:
+			warnings = append(warnings, fmt.Sprintf("track %d follows track %d", cur, prev))
+		case cur > prev+1:
+			warnings = append(warnings, fmt.Sprintf("track numbers skip from %d to %d", prev, cur))
+		}
+	}
+	return warnings
+}
+
 // GetAudioFilePath returns the full path to the audio file
 func (c *CueFile) GetAudioFilePath() string {
 	if c.AudioFile == "" {
@@ -450,12 +800,115 @@ func (c *CueFile) GetAudioFilePath() string {
 	}
 
 	// If AudioFile is absolute, return it
-	if filepath.IsAbs(c.AudioFile) {
+	if filepath.IsAbs(c.AudioFile) || isWindowsAbs(c.AudioFile) {
 		return c.AudioFile
 	}
 
 	// Otherwise, join with CUE file directory
-	return filepath.Join(filepath.Dir(c.Path), c.AudioFile)
+	return filepath.Join(filepath.Dir(c.Path), localPath(c.AudioFile))
+}
+
+// GetCDTextFilePath returns the full path of the CDTEXTFILE, or "" if none
+func (c *CueFile) GetCDTextFilePath() string {
+	if c.CDTextFile == "" {
+		return ""
+	}
+	if filepath.IsAbs(c.CDTextFile) || isWindowsAbs(c.CDTextFile) {
+		return c.CDTextFile
+	}
+	return filepath.Join(filepath.Dir(c.Path), localPath(c.CDTextFile))
+}
+
+// localPath converts the backslashes of a FILE path written on Windows, e.g.
+// FILE "CD1\audio.flac", to the OS path separator
+func localPath(path string) string {
+	return filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+}
+
+// isWindowsAbs reports whether path is an absolute Windows path such as
+// C:\Music\album.flac or \\server\share\album.flac, on any OS
+func isWindowsAbs(path string) bool {
+	if strings.HasPrefix(path, `\\`) {
+		return true
+	}
+	return len(path) >= 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/') &&
+		('a' <= path[0]|0x20 && path[0]|0x20 <= 'z')
+}
+
+// audioExtensions lists source audio extensions considered when the FILE
+// directive does not point to an existing file
+var audioExtensions = map[string]bool{
+	".flac": true, ".wav": true, ".ape": true, ".wv": true, ".m4a": true,
+}
+
+// FindAudioFile returns the path to the audio file. When the file referenced
+// by FILE is missing, it falls back to a single matching audio file in the
+// CUE's directory: first by the referenced extension, then any audio file,
+// then by name similarity to the CUE or referenced file name.
+func (c *CueFile) FindAudioFile() (string, error) {
+	audioPath := c.GetAudioFilePath()
+	if audioPath != "" {
+		if _, err := os.Stat(audioPath); err == nil {
+			return audioPath, nil
+		}
+	}
+
+	dir := filepath.Dir(c.Path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read CUE directory: %w", err)
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if audioExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			candidates = append(candidates, entry.Name())
+		}
+	}
+
+	refExt := strings.ToLower(filepath.Ext(c.AudioFile))
+	var sameType []string
+	for _, name := range candidates {
+		if strings.ToLower(filepath.Ext(name)) == refExt {
+			sameType = append(sameType, name)
+		}
+	}
+
+	switch {
+	case len(sameType) == 1:
+		return filepath.Join(dir, sameType[0]), nil
+	case len(candidates) == 1:
+		return filepath.Join(dir, candidates[0]), nil
+	case len(candidates) == 0:
+		return "", fmt.Errorf("%w: %s", ErrAudioNotFound, audioPath)
+	}
+
+	// Several candidates: accept one whose name matches the CUE or the FILE entry
+	stems := map[string]bool{
+		stem(c.FileName):             true,
+		stem(localPath(c.AudioFile)): true,
+	}
+	var similar []string
+	for _, name := range candidates {
+		if stems[stem(name)] {
+			similar = append(similar, name)
+		}
+	}
+	if len(similar) == 1 {
+		return filepath.Join(dir, similar[0]), nil
+	}
+
+	return "", fmt.Errorf("%w: %s (%d ambiguous candidates in %s)",
+		ErrAudioNotFound, audioPath, len(candidates), dir)
+}
+
+// stem returns the lowercased base filename without its extension
+func stem(name string) string {
+	base := filepath.Base(name)
+	return strings.ToLower(strings.TrimSuffix(base, filepath.Ext(base)))
 }
 
 // HasCustomField checks if a custom field exists
@@ -474,12 +927,30 @@ func (c *CueFile) TrackCount() int {
 	return len(c.Tracks)
 }
 
-// GetTrack returns a track by number (1-based)
+// GetTrack returns the track with the given CUE track number, or nil
 func (c *CueFile) GetTrack(number int) *Track {
-	if number < 1 || number > len(c.Tracks) {
-		return nil
+	for i := range c.Tracks {
+		if c.Tracks[i].Number == number {
+			return &c.Tracks[i]
+		}
+	}
+	return nil
+}
+
+// AudioTracks returns the tracks that hold audio, leaving out data tracks
+func (c *CueFile) AudioTracks() []Track {
+	var tracks []Track
+	for _, track := range c.Tracks {
+		if track.IsAudio() {
+			tracks = append(tracks, track)
+		}
 	}
-	return &c.Tracks[number-1]
+	return tracks
+}
+
+// IsAudio reports whether the track holds audio rather than data
+func (t *Track) IsAudio() bool {
+	return t.Type == "" || t.Type == "AUDIO"
 }
 
 // HasCustomField checks if a track has a custom field
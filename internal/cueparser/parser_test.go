@@ -0,0 +1,304 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cueparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// sampleCueLines is a small CUE sheet, one line per entry
+var sampleCueLines = []string{
+	`PERFORMER "Artist"`,
+	`TITLE "Album"`,
+	`FILE "album.flac" WAVE`,
+	`  TRACK 01 AUDIO`,
+	`    TITLE "One"`,
+	`    INDEX 01 00:00:00`,
+	`  TRACK 02 AUDIO`,
+	`    TITLE "Two"`,
+	`    INDEX 00 03:10:20`,
+	`    INDEX 01 03:12:00`,
+}
+
+// checkSampleCue fails the test unless cue holds sampleCueLines
+func checkSampleCue(t *testing.T, cue *CueFile) {
+	t.Helper()
+	if cue.Performer != "Artist" || cue.Album != "Album" || cue.AudioFile != "album.flac" {
+		t.Errorf("album = %q by %q in %q, want \"Album\" by \"Artist\" in \"album.flac\"",
+			cue.Album, cue.Performer, cue.AudioFile)
+	}
+	if len(cue.Tracks) != 2 {
+		t.Fatalf("got %d tracks, want 2", len(cue.Tracks))
+	}
+	last := cue.Tracks[1]
+	if last.Title != "Two" || last.PreGap != "03:10:20" || last.Index != "03:12:00" {
+		t.Errorf("track 2 = %q INDEX 00 %q INDEX 01 %q, want \"Two\" 03:10:20 03:12:00",
+			last.Title, last.PreGap, last.Index)
+	}
+}
+
+func TestParseReaderLineEndings(t *testing.T) {
+	for _, ending := range []string{"\n", "\r\n", "\r"} {
+		for _, trailing := range []bool{true, false} {
+			text := strings.Join(sampleCueLines, ending)
+			if trailing {
+				text += ending
+			}
+			name := strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace(ending)
+			if !trailing {
+				name += " without trailing newline"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				var cue CueFile
+				// One byte at a time puts every \r at the end of the buffer
+				r := iotest.OneByteReader(strings.NewReader(text))
+				if err := ParseReader(&cue, r, DefaultConfig()); err != nil {
+					t.Fatal(err)
+				}
+				checkSampleCue(t, &cue)
+			})
+		}
+	}
+}
+
+// encodeUTF16 returns text as UTF-16 with a byte order mark
+func encodeUTF16(text string, order binary.AppendByteOrder) []byte {
+	units := utf16.Encode([]rune("\uFEFF" + text))
+	data := make([]byte, 0, 2*len(units))
+	for _, unit := range units {
+		data = order.AppendUint16(data, unit)
+	}
+	return data
+}
+
+func TestParseReaderEncodings(t *testing.T) {
+	lines := slices.Clone(sampleCueLines)
+	lines[0] = `PERFORMER "Sigur Rós – 日本 🎵"`
+	text := strings.Join(lines, "\r\n") + "\r\n"
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"UTF-8", []byte(text)},
+		{"UTF-8 BOM", append([]byte("\xEF\xBB\xBF"), text...)},
+		{"UTF-16LE", encodeUTF16(text, binary.LittleEndian)},
+		{"UTF-16BE", encodeUTF16(text, binary.BigEndian)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cue CueFile
+			if err := ParseReader(&cue, bytes.NewReader(tt.data), DefaultConfig()); err != nil {
+				t.Fatal(err)
+			}
+			// A BOM left in front of the first line would hide PERFORMER
+			if want := "Sigur Rós – 日本 🎵"; cue.Performer != want {
+				t.Errorf("Performer = %q, want %q", cue.Performer, want)
+			}
+			if len(cue.Tracks) != 2 || cue.Tracks[1].Index != "03:12:00" {
+				t.Errorf("got tracks %+v, want 2 with track 2 at 03:12:00", cue.Tracks)
+			}
+		})
+	}
+}
+
+func TestDecodeBOMWithoutBOM(t *testing.T) {
+	// Latin-1 and other legacy encodings have no BOM and are left alone
+	data := []byte("TITLE \"Caf\xe9\"\r\n")
+	got, err := io.ReadAll(DecodeBOM(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("DecodeBOM() = %q, want %q unchanged", got, data)
+	}
+}
+
+func TestScanLines(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"a\nb\n", []string{"a", "b"}},
+		{"a\r\nb\r\n", []string{"a", "b"}},
+		{"a\rb\r", []string{"a", "b"}},
+		{"a\rb", []string{"a", "b"}},
+		{"a\n\nb", []string{"a", "", "b"}},
+		{"a\r\rb", []string{"a", "", "b"}},
+		{"a\r\n\r\nb", []string{"a", "", "b"}},
+		{"a\n\rb", []string{"a", "", "b"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		scanner := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(tt.input)))
+		scanner.Split(ScanLines)
+		var got []string
+		for scanner.Scan() {
+			got = append(got, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ScanLines(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseReaderDuplicateTrackNumbers(t *testing.T) {
+	text := strings.Join([]string{
+		`FILE "album.flac" WAVE`,
+		`  TRACK 01 AUDIO`,
+		`    TITLE "One"`,
+		`    INDEX 01 00:00:00`,
+		`  TRACK 02 AUDIO`,
+		`    TITLE "Two"`,
+		`    INDEX 01 01:00:00`,
+		`  TRACK 01 AUDIO`,
+		`    TITLE "One again"`,
+		`    INDEX 01 02:00:00`,
+	}, "\n")
+
+	t.Run("warning", func(t *testing.T) {
+		config := DefaultConfig()
+		config.CollectWarnings = true
+		var cue CueFile
+		if err := ParseReader(&cue, strings.NewReader(text), config); err != nil {
+			t.Fatal(err)
+		}
+		if len(cue.Tracks) != 3 {
+			t.Errorf("got %d tracks, want all 3", len(cue.Tracks))
+		}
+		want := []ParseWarning{{Line: 8, Text: "  TRACK 01 AUDIO", Message: "track number 1 is already used on line 2"}}
+		if !slices.Equal(cue.Warnings, want) {
+			t.Errorf("Warnings = %v, want %v", cue.Warnings, want)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		config := DefaultConfig()
+		config.StrictMode = true
+		var cue CueFile
+		err := ParseReader(&cue, strings.NewReader(text), config)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Line != 8 {
+			t.Errorf("ParseReader() error = %v, want a ParseError on line 8", err)
+		}
+	})
+}
+
+func TestParseReaderLongTimecodes(t *testing.T) {
+	// A 110-minute concert in one file: minutes run past 99, and the last
+	// track is written in the four-field form
+	text := strings.Join([]string{
+		`FILE "concert.flac" WAVE`,
+		`  TRACK 01 AUDIO`,
+		`    INDEX 01 00:00:00`,
+		`  TRACK 02 AUDIO`,
+		`    INDEX 00 99:58:00`,
+		`    INDEX 01 99:59:74`,
+		`  TRACK 03 AUDIO`,
+		`    PREGAP 00:00:02:00`,
+		`    INDEX 00 01:44:58:00`,
+		`    INDEX 01 01:45:00:12`,
+		`    POSTGAP 00:00:01:00`,
+	}, "\n")
+
+	var cue CueFile
+	if err := ParseReader(&cue, strings.NewReader(text), DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Track{
+		{Number: 1, Index: "00:00:00"},
+		{Number: 2, PreGap: "99:58:00", Index: "99:59:74"},
+		{Number: 3, PreGap: "01:44:58:00", Index: "01:45:00:12", PregapSilence: "00:00:02:00", PostgapSilence: "00:00:01:00"},
+	}
+	if len(cue.Tracks) != len(want) {
+		t.Fatalf("got %d tracks, want %d", len(cue.Tracks), len(want))
+	}
+	for i, track := range cue.Tracks {
+		w := want[i]
+		if track.Number != w.Number || track.Index != w.Index || track.PreGap != w.PreGap ||
+			track.PregapSilence != w.PregapSilence || track.PostgapSilence != w.PostgapSilence {
+			t.Errorf("track %d = %+v, want %+v", i+1, track, w)
+		}
+	}
+}
+
+func TestGetAudioFilePath(t *testing.T) {
+	cuePath := filepath.Join("music", "album", "album.cue")
+
+	tests := []struct {
+		name, file, want string
+	}{
+		{"plain", "audio.flac", filepath.Join("music", "album", "audio.flac")},
+		{"posix relative", "CD1/audio.flac", filepath.Join("music", "album", "CD1", "audio.flac")},
+		{"windows relative", `CD1\audio.flac`, filepath.Join("music", "album", "CD1", "audio.flac")},
+		{"windows parent", `..\other\audio.flac`, filepath.Join("music", "other", "audio.flac")},
+		{"windows drive", `C:\Music\audio.flac`, `C:\Music\audio.flac`},
+		{"windows drive with slashes", `d:/Music/audio.flac`, `d:/Music/audio.flac`},
+		{"windows UNC", `\\server\share\audio.flac`, `\\server\share\audio.flac`},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct{ name, file, want string }{
+			"posix absolute", "/srv/music/audio.flac", "/srv/music/audio.flac",
+		})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cue := CueFile{Path: cuePath, AudioFile: tt.file}
+			if got := cue.GetAudioFilePath(); got != tt.want {
+				t.Errorf("GetAudioFilePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindAudioFileBackslashPath(t *testing.T) {
+	dir := t.TempDir()
+	want := filepath.Join(dir, "CD1", "audio.flac")
+	if err := os.MkdirAll(filepath.Dir(want), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(want, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cue := CueFile{Path: filepath.Join(dir, "album.cue"), AudioFile: `CD1\audio.flac`}
+	got, err := cue.FindAudioFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("FindAudioFile() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cueparser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Custom fields filled from a rip log by ApplyRipLog
+const (
+	FieldRipper      = "RIPPER"
+	FieldRipDrive    = "RIPDRIVE"
+	FieldGapHandling = "GAPHANDLING"
+	FieldAccurateRip = "ACCURATERIP"
+)
+
+// RipLogFields lists the custom fields ApplyRipLog may set
+var RipLogFields = []string{FieldRipper, FieldRipDrive, FieldGapHandling, FieldAccurateRip}
+
+// RipLog holds what an EAC or XLD extraction log says about a rip
+type RipLog struct {
+	Ripper      string         // e.g. "Exact Audio Copy V1.6"
+	Drive       string         // the drive used
+	GapHandling string         // e.g. "Appended to previous track"
+	AccurateRip string         // the summary for the whole disc
+	Tracks      map[int]string // AccurateRip result by track number
+}
+
+var (
+	ripLogTrack   = regexp.MustCompile(`^\s*Track\s+(\d+)\s*$`)
+	ripLogSetting = regexp.MustCompile(`^\s*(Used drive|Gap handling|Gap status)\s*:\s*(.+?)\s*$`)
+	// Per-track and summary AccurateRip results of EAC and XLD
+	ripLogAccurate = regexp.MustCompile(`(?i)^(?:->)?\s*((?:all |no |some |\d+ )?tracks? .*accurate.*|accurately ripped.*|` +
+		`cannot be verified.*|rip may not be accurate.*|.*not present in (?:the )?accuraterip database.*|` +
+		`none of the tracks.*|not found.*)$`)
+	ripLogSummary = regexp.MustCompile(`(?i)^(?:all|no|some|none of the|\d+) tracks? `)
+	// EAC's AccurateRip summary lists "Track  1  accurately ripped (...)"
+	ripLogTrackResult = regexp.MustCompile(`(?i)^Track\s+(\d+)\s+(.*accura.*)$`)
+)
+
+// FindRipLog returns the rip log beside the CUE sheet at cuePath: the .log
+// file with the same name, or else the only .log file in its directory.
+// It returns "" when there is none.
+func FindRipLog(cuePath string) string {
+	sameName := strings.TrimSuffix(cuePath, filepath.Ext(cuePath)) + ".log"
+	if _, err := os.Stat(sameName); err == nil {
+		return sameName
+	}
+
+	logs, _ := filepath.Glob(filepath.Join(filepath.Dir(cuePath), "*.[lL][oO][gG]"))
+	if len(logs) == 1 {
+		return logs[0]
+	}
+	return ""
+}
+
+// ReadRipLog reads and parses an EAC or XLD log file, which EAC writes as
+// UTF-16 with a byte order mark
+func ReadRipLog(path string) (*RipLog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rip log: %w", err)
+	}
+	return ParseRipLog(data)
+}
+
+// ParseRipLog extracts the ripper, drive, gap handling and AccurateRip
+// results from the text of an EAC or XLD log. Lines it does not know are
+// skipped, so other logs just yield an empty RipLog.
+func ParseRipLog(data []byte) (*RipLog, error) {
+	log := &RipLog{Tracks: make(map[int]string)}
+
+	scanner := bufio.NewScanner(DecodeBOM(bytes.NewReader(data)))
+	scanner.Split(ScanLines)
+	track := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if log.Ripper == "" {
+			switch {
+			case strings.HasPrefix(line, "Exact Audio Copy"):
+				// "Exact Audio Copy V1.6 from 23. October 2020"
+				log.Ripper, _, _ = strings.Cut(line, " from ")
+				continue
+			case strings.HasPrefix(line, "X Lossless Decoder"), strings.HasPrefix(line, "XLD "):
+				log.Ripper = line
+				continue
+			}
+		}
+
+		if matches := ripLogTrack.FindStringSubmatch(line); matches != nil {
+			track, _ = strconv.Atoi(matches[1])
+			continue
+		}
+		if matches := ripLogSetting.FindStringSubmatch(line); matches != nil {
+			switch matches[1] {
+			case "Used drive":
+				if log.Drive == "" {
+					// EAC appends "Adapter: 1  ID: 0" to the drive name
+					drive, _, _ := strings.Cut(matches[2], "Adapter:")
+					log.Drive = strings.Join(strings.Fields(drive), " ")
+				}
+			default:
+				if log.GapHandling == "" {
+					log.GapHandling = matches[2]
+				}
+			}
+			continue
+		}
+		if matches := ripLogTrackResult.FindStringSubmatch(line); matches != nil {
+			number, _ := strconv.Atoi(matches[1])
+			if log.Tracks[number] == "" {
+				log.Tracks[number] = strings.Join(strings.Fields(matches[2]), " ")
+			}
+			continue
+		}
+		if matches := ripLogAccurate.FindStringSubmatch(line); matches != nil {
+			result := strings.Join(strings.Fields(strings.TrimSuffix(matches[1], ".")), " ")
+			// Per-track results are inside a track section, summaries follow them
+			if track == 0 || ripLogSummary.MatchString(result) {
+				log.AccurateRip = result
+			} else if log.Tracks[track] == "" {
+				log.Tracks[track] = result
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rip log: %w", err)
+	}
+	return log, nil
+}
+
+// ApplyRipLog stores what the rip log says as custom fields (see
+// RipLogFields) that the CUE sheet does not already set
+func (c *CueFile) ApplyRipLog(log *RipLog) {
+	if log == nil {
+		return
+	}
+	fill := func(fields map[string]string, key, value string) {
+		if fields != nil && value != "" && fields[key] == "" {
+			fields[key] = value
+		}
+	}
+
+	fill(c.CustomFields, FieldRipper, log.Ripper)
+	fill(c.CustomFields, FieldRipDrive, log.Drive)
+	fill(c.CustomFields, FieldGapHandling, log.GapHandling)
+	fill(c.CustomFields, FieldAccurateRip, log.AccurateRip)
+	for i := range c.Tracks {
+		fill(c.Tracks[i].CustomFields, FieldAccurateRip, log.Tracks[c.Tracks[i].Number])
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cueparser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ParseWarning describes a suspicious CUE sheet line: one that looks like a
+// directive but was not understood by the parser, or a reused track number
+type ParseWarning struct {
+	Line    int    // 1-based line number
+	Text    string // the line as written
+	Message string
+}
+
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("line %d: %s: %s", w.Line, w.Message, strings.TrimSpace(w.Text))
+}
+
+// ignoredDirectives are valid CUE commands the parser deliberately skips
+var ignoredDirectives = map[string]bool{
+	"FLAGS": true,
+}
+
+// trackDirectives are only valid inside a TRACK block
+var trackDirectives = map[string]bool{
+	"INDEX": true, "PREGAP": true, "POSTGAP": true, "ISRC": true, "FLAGS": true,
+}
+
+// knownDirectives are the CUE commands the parser reads
+var knownDirectives = map[string]bool{
+	"FILE": true, "TRACK": true, "INDEX": true, "TITLE": true, "PERFORMER": true,
+	"SONGWRITER": true, "COMPOSER": true, "ISRC": true, "CATALOG": true,
+	"PREGAP": true, "POSTGAP": true, "CDTEXTFILE": true,
+}
+
+var (
+	directiveWord = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	otherIndex    = regexp.MustCompile(`^\s*INDEX\s+\d{2}\s+\d+:\d+(?::\d+|\.\d+)\s*$`)
+)
+
+// unmatchedDirective explains why a line that no pattern matched looks
+// wrong, or returns "" for blank lines and lines that are not directives
+func unmatchedDirective(line string, inTrack bool) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !directiveWord.MatchString(fields[0]) {
+		return ""
+	}
+	word := fields[0]
+
+	switch {
+	case trackDirectives[word] && !inTrack:
+		return word + " outside of a TRACK block"
+	case ignoredDirectives[word]:
+		return ""
+	case word == "INDEX" && otherIndex.MatchString(line):
+		// Indexes other than 00 and 01 are valid but unused
+		return ""
+	case knownDirectives[word]:
+		return "malformed " + word
+	default:
+		return "unknown directive " + word
+	}
+}
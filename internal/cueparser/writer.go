@@ -0,0 +1,171 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cueparser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TrackFile names the audio file holding one track for WriteTracks
+type TrackFile struct {
+	Name string
+
+	// GapInPrevious places the track's INDEX 00 at the end of the previous
+	// track's file, as in EAC's "gaps appended to previous tracks" layout;
+	// otherwise the gap is at the start of the track's own file
+	GapInPrevious bool
+}
+
+// Write renders cue as a CUE sheet that Parse reads back: album metadata as
+// REM, PERFORMER and TITLE lines, then one FILE entry with every track.
+// Empty fields and track performers equal to the album's are left out, and
+// double quotes in values become single quotes, which the format cannot escape.
+func Write(w io.Writer, cue *CueFile) error {
+	return writeSheet(w, cue, nil)
+}
+
+// WriteTracks renders cue like Write for an album stored as one file per
+// track, files[i] holding cue.Tracks[i]: every track gets its own FILE
+// entry, and its INDEX values are offsets into the file they fall in.
+func WriteTracks(w io.Writer, cue *CueFile, files []TrackFile) error {
+	if len(files) != len(cue.Tracks) {
+		return fmt.Errorf("got %d track files for %d tracks", len(files), len(cue.Tracks))
+	}
+	return writeSheet(w, cue, files)
+}
+
+// writeSheet writes cue with a FILE entry per track from files, or a single
+// one naming cue.AudioFile when files is nil
+func writeSheet(w io.Writer, cue *CueFile, files []TrackFile) error {
+	bw := bufio.NewWriter(w)
+	rem := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(bw, "REM %s %s\n", key, value)
+		}
+	}
+	quoted := func(indent, key, value string) {
+		if value != "" {
+			fmt.Fprintf(bw, "%s%s %s\n", indent, key, quote(value))
+		}
+	}
+
+	rem("GENRE", quoteREM(cue.Genre))
+	rem("DATE", cue.Date)
+	if cue.DiscNumber != "" {
+		disc := cue.DiscNumber
+		if cue.TotalDiscs != "" {
+			disc += "/" + cue.TotalDiscs
+		}
+		rem("DISCNUMBER", disc)
+	}
+	rem("DISCID", cue.DiscID)
+	rem("COMMENT", quoteREM(cue.Comment))
+	rem("REPLAYGAIN_ALBUM_GAIN", cue.ReplayGainAlbumGain)
+	rem("REPLAYGAIN_ALBUM_PEAK", cue.ReplayGainAlbumPeak)
+	for _, key := range sortedKeys(cue.CustomFields) {
+		rem(key, cue.CustomFields[key])
+	}
+	if cue.Catalog != "" {
+		fmt.Fprintf(bw, "CATALOG %s\n", cue.Catalog)
+	}
+	quoted("", "PERFORMER", cue.Performer)
+	quoted("", "TITLE", cue.Album)
+	quoted("", "COMPOSER", cue.Composer)
+	quoted("", "SONGWRITER", cue.Songwriter)
+
+	fileType := cue.AudioFileType
+	if fileType == "" {
+		fileType = "WAVE"
+	}
+	file := func(name string) {
+		fmt.Fprintf(bw, "FILE %s %s\n", quote(name), fileType)
+	}
+	if files == nil {
+		file(cue.AudioFile)
+	}
+
+	for i, track := range cue.Tracks {
+		gapInPrevious := files != nil && i > 0 && files[i].GapInPrevious && track.PreGap != ""
+		if files != nil && !gapInPrevious {
+			file(files[i].Name)
+		}
+
+		trackType := track.Type
+		if trackType == "" {
+			trackType = "AUDIO"
+		}
+		fmt.Fprintf(bw, "  TRACK %02d %s\n", track.Number, trackType)
+		quoted("    ", "TITLE", track.Title)
+		if track.Performer != cue.Performer {
+			quoted("    ", "PERFORMER", track.Performer)
+		}
+		quoted("    ", "COMPOSER", track.Composer)
+		quoted("    ", "SONGWRITER", track.Songwriter)
+		if track.ISRC != "" {
+			fmt.Fprintf(bw, "    ISRC %s\n", track.ISRC)
+		}
+		if track.ReplayGainTrackGain != "" {
+			fmt.Fprintf(bw, "    REM REPLAYGAIN_TRACK_GAIN %s\n", track.ReplayGainTrackGain)
+		}
+		if track.ReplayGainTrackPeak != "" {
+			fmt.Fprintf(bw, "    REM REPLAYGAIN_TRACK_PEAK %s\n", track.ReplayGainTrackPeak)
+		}
+		for _, key := range sortedKeys(track.CustomFields) {
+			fmt.Fprintf(bw, "    REM %s %s\n", key, track.CustomFields[key])
+		}
+		if track.PregapSilence != "" {
+			fmt.Fprintf(bw, "    PREGAP %s\n", track.PregapSilence)
+		}
+		if track.PreGap != "" {
+			fmt.Fprintf(bw, "    INDEX 00 %s\n", track.PreGap)
+		}
+		if gapInPrevious {
+			file(files[i].Name)
+		}
+		fmt.Fprintf(bw, "    INDEX 01 %s\n", track.Index)
+		if track.PostgapSilence != "" {
+			fmt.Fprintf(bw, "    POSTGAP %s\n", track.PostgapSilence)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// quote wraps a value in double quotes, replacing any inside it
+func quote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+}
+
+// quoteREM quotes a free-text REM value that contains spaces
+func quoteREM(value string) string {
+	if strings.Contains(value, " ") {
+		return quote(value)
+	}
+	return value
+}
+
+// sortedKeys returns the keys of fields in sorted order
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
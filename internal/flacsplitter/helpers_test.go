@@ -0,0 +1,202 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"crypto/md5"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+	"github.com/mewkiz/flac/meta"
+)
+
+// testSampleRate is the sample rate of generated test audio; one CUE frame
+// is exactly 588 samples at it
+const testSampleRate = 44100
+
+// testSignal returns n samples per channel of a deterministic 16-bit signal:
+// a tone per channel with a little noise, so no two channels are equal
+func testSignal(channels, n int) [][]int32 {
+	samples := make([][]int32, channels)
+	seed := uint32(1)
+	for ch := range samples {
+		samples[ch] = make([]int32, n)
+		freq := 220 * float64(ch+1)
+		for i := range samples[ch] {
+			seed = seed*1664525 + 1013904223
+			noise := int32(seed>>24) - 128
+			tone := 12000 * math.Sin(2*math.Pi*freq*float64(i)/testSampleRate)
+			samples[ch][i] = int32(tone) + noise
+		}
+	}
+	return samples
+}
+
+// writeTestFlac encodes 16-bit samples to a FLAC file at path with
+// NewFlacWriter and returns the STREAMINFO it was written with
+func writeTestFlac(t testing.TB, path string, samples [][]int32) *meta.StreamInfo {
+	t.Helper()
+	info := &meta.StreamInfo{
+		SampleRate:    testSampleRate,
+		NChannels:     uint8(len(samples)),
+		BitsPerSample: 16,
+		NSamples:      uint64(len(samples[0])),
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w, err := NewFlacWriter(file, info, DefaultBlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for start := 0; start < len(samples[0]); start += DefaultBlockSize {
+		end := min(start+DefaultBlockSize, len(samples[0]))
+		block := make([][]int32, len(samples))
+		for ch := range samples {
+			block[ch] = samples[ch][start:end]
+		}
+		if err := w.WriteBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+// clearSampleCount zeroes the total sample count in the STREAMINFO of the
+// FLAC file at path, as encoders that do not know the length up front do
+func clearSampleCount(t testing.TB, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The 36-bit count starts in the low nibble of byte 13 of STREAMINFO,
+	// which follows the "fLaC" marker and a 4-byte block header
+	const offset = 4 + 4 + 13
+	data[offset] &= 0xf0
+	clear(data[offset+1 : offset+5])
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readTestFlac decodes every sample of the FLAC file at path
+func readTestFlac(t testing.TB, path string) ([][]int32, *meta.StreamInfo) {
+	t.Helper()
+	r, err := OpenFlacReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	info := r.Info()
+	samples := make([][]int32, info.NChannels)
+	for {
+		block, err := r.ReadBlock()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		for ch := range block {
+			samples[ch] = append(samples[ch], block[ch]...)
+		}
+	}
+	return samples, info
+}
+
+// samplesMD5 returns the STREAMINFO style MD5 signature of 16-bit samples
+func samplesMD5(samples [][]int32) [16]byte {
+	h := md5.New()
+	hashSamples(h, samples, 0, uint64(len(samples[0])), 16)
+	var sum [16]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// concatSamples appends the samples of every part, channel by channel
+func concatSamples(parts ...[][]int32) [][]int32 {
+	joined := make([][]int32, len(parts[0]))
+	for _, part := range parts {
+		for ch := range part {
+			joined[ch] = append(joined[ch], part[ch]...)
+		}
+	}
+	return joined
+}
+
+// writeTestAlbum writes seconds of stereo test audio to album.flac in a new
+// directory, with a CUE sheet made of the given lines after the FILE entry,
+// and returns the parsed CUE, the FLAC path and the source samples
+func writeTestAlbum(t testing.TB, seconds float64, trackLines ...string) (cueparser.CueFile, string, [][]int32) {
+	t.Helper()
+	dir := t.TempDir()
+	flacPath := filepath.Join(dir, "album.flac")
+	samples := testSignal(2, int(seconds*testSampleRate))
+	writeTestFlac(t, flacPath, samples)
+
+	text := "PERFORMER \"Artist\"\nTITLE \"Album\"\nFILE \"album.flac\" WAVE\n" +
+		strings.Join(trackLines, "\n") + "\n"
+	cuePath := filepath.Join(dir, "album.cue")
+	if err := os.WriteFile(cuePath, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cue := cueparser.CueFile{Path: cuePath, FileName: "album.cue"}
+	if err := cueparser.Parse(&cue); err != nil {
+		t.Fatal(err)
+	}
+	return cue, flacPath, samples
+}
+
+// testOptions returns pure Go options writing to a new directory
+func testOptions(t testing.TB) *SplitOptions {
+	t.Helper()
+	opts := DefaultOptions(t.TempDir())
+	opts.SkipSpaceCheck = true
+	return opts
+}
+
+// outputTracks returns the FLAC files written below dir, sorted by path
+func outputTracks(t testing.TB, dir string) []string {
+	t.Helper()
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ".flac" {
+			paths = append(paths, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(paths)
+	return paths
+}
@@ -15,8 +15,19 @@
 package flacsplitter
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/ldmonster/flac-splitter/internal/cueparser"
 )
@@ -31,30 +42,481 @@ const (
 	ModeGoAudio
 	// ModeGoAudioFull uses pure Go FLAC encoding (no external tools)
 	ModeGoAudioFull
+	// ModeChapterize keeps a single output file and writes chapter markers
+	ModeChapterize
+)
+
+// OutputFormat selects the container written for each track
+type OutputFormat string
+
+const (
+	// OutputFLAC writes tagged FLAC files (default)
+	OutputFLAC OutputFormat = "flac"
+	// OutputWAV writes untagged RIFF/WAVE files (pure Go mode only)
+	OutputWAV OutputFormat = "wav"
+)
+
+// PregapMode controls how PREGAP and POSTGAP silence commands are handled
+type PregapMode int
+
+const (
+	// PregapIgnore ignores PREGAP and POSTGAP commands
+	PregapIgnore PregapMode = iota
+	// PregapInsertSilence pads tracks with the silence they describe (pure Go mode only)
+	PregapInsertSilence
+)
+
+// BoundaryMode controls which track the gap between a track's INDEX 00 and
+// INDEX 01 belongs to
+type BoundaryMode int
+
+const (
+	// BoundaryIndex01 ends each track at the next track's INDEX 01, so the
+	// gap (silence, applause) is the tail of the previous track
+	BoundaryIndex01 BoundaryMode = iota
+	// BoundaryIndex00 ends each track at the next track's INDEX 00, so the
+	// gap is the start of the track it belongs to in the CUE sheet
+	BoundaryIndex00
+	// BoundaryDropGaps starts each track at its INDEX 01 and ends it at the
+	// next track's INDEX 00, so the gap is left out of every track
+	BoundaryDropGaps
+)
+
+// HiddenTrackMode controls what happens to audio before the first track's
+// INDEX 01 (hidden track one audio, HTOA)
+type HiddenTrackMode int
+
+const (
+	// HiddenTrackDiscard drops the hidden audio
+	HiddenTrackDiscard HiddenTrackMode = iota
+	// HiddenTrackSeparate writes the hidden audio as track 0 (pure Go mode only)
+	HiddenTrackSeparate
+	// HiddenTrackPrepend adds the hidden audio to the start of the first track (pure Go mode only)
+	HiddenTrackPrepend
+)
+
+// ShortTrackMode controls what happens to tracks shorter than
+// SplitOptions.MinTrackDuration
+type ShortTrackMode int
+
+const (
+	// ShortTrackSkip leaves short tracks out of the output; their audio is
+	// not part of any other track
+	ShortTrackSkip ShortTrackMode = iota
+	// ShortTrackMerge adds short tracks to the end of the previous track, or
+	// to the start of the next one for the first track
+	ShortTrackMerge
 )
 
 // SplitOptions holds configuration for FLAC splitting
 type SplitOptions struct {
 	OutputDir       string
-	FilenamePattern string // e.g., "%02d - %s.flac"
+	FilenamePattern string // e.g., "%02d - %s.flac"; {disc} is replaced by the disc number, %02d widens for 100+ tracks
 	OverwriteFiles  bool
-	UseFFmpeg       bool      // Prefer ffmpeg over shnsplit (only for external mode)
+	UseFFmpeg       bool      // Prefer ffmpeg over shnsplit (external and hybrid modes)
 	Mode            SplitMode // Which splitter implementation to use
+	ChaptersSidecar bool      // Also write a .chapters.txt file (only for chapterize mode)
+	EmbedTags       bool      // Also replace the Vorbis comments with album tags in EmbedCue
+	StrictTimecodes bool      // Reject out-of-range or malformed CUE timecodes
+	VariousArtists  bool      // Tag ALBUMARTIST as VariousArtistsName when track performers differ
+
+	ExternalTimeout time.Duration // Kill each shnsplit/ffmpeg run after this long (0 = no limit)
+
+	// KeepTempFiles keeps the CUE sheet written for shnsplit for debugging
+	KeepTempFiles bool
+
+	// MaxRetries re-runs an external tool up to this many times, with
+	// exponential backoff, when it fails with a transient I/O error
+	MaxRetries int
+
+	// Tool forces the named external splitter, e.g. "sox" (empty selects automatically)
+	Tool string
+
+	// Splitters registers additional external splitters; they are tried
+	// before the built-in shnsplit, ffmpeg and sox entries
+	Splitters []ExternalSplitter
+
+	// TagMapping controls which Vorbis comments each field is written as
+	// (nil uses DefaultTagMapping)
+	TagMapping TagMapping
+
+	// WriteCustomFields writes every custom REM field as a Vorbis comment
+	WriteCustomFields bool
+
+	// Verbose logs the Vorbis comments written to every track
+	Verbose bool
+
+	// Fingerprint tags every FLAC track with its Chromaprint fingerprint as
+	// ACOUSTID_FINGERPRINT, computed by fpcalc, which must be installed. Not
+	// available in chapters mode or with SplitStream.
+	Fingerprint bool
+
+	// TrackNumberFormat and DiscNumberFormat control how TRACKNUMBER and
+	// DISCNUMBER are written (empty means NumberPlain)
+	TrackNumberFormat NumberFormat
+	DiscNumberFormat  NumberFormat
+
+	// VendorString replaces the Vorbis comment vendor string of tagged files
+	// (empty keeps the vendor string the file already has)
+	VendorString string
+
+	MaxFilenameLength int // Maximum output filename length in bytes (0 = unlimited)
+
+	// FilenameRules controls how characters that are invalid in filenames
+	// are replaced in track titles and album names (zero value: "_")
+	FilenameRules FilenameRules
+
+	// Gapless makes the pure Go tracks cover the whole stream from sample 0
+	// and verifies their concatenation is bit-identical to the source
+	Gapless bool
+
+	// DiscSubfolder writes the tracks of a numbered disc into a "Disc N"
+	// folder below OutputDir (see DiscFolderName)
+	DiscSubfolder bool
+
+	// SkipSpaceCheck disables the check that OutputDir is writable and has
+	// room for the split tracks before anything is written
+	SkipSpaceCheck bool
+
+	// FallbackToExternal retries an album with external tools when pure Go
+	// mode fails or cannot read the source, unless an option only pure Go
+	// mode supports is set
+	FallbackToExternal bool
+
+	// WriteManifest writes a "<album>.sha256" file listing the SHA256 of
+	// every output file, checkable with sha256sum -c
+	WriteManifest bool
+
+	// RenameDuplicates appends " (2)", " (3)", ... to tracks that would be
+	// written to the same file as an earlier track; without it such albums
+	// fail with ErrDuplicateOutput before anything is written
+	RenameDuplicates bool
+
+	// ExtractCoverTo, if set, writes the front cover of a FLAC source to a
+	// file of this name in the album output directory (adding an extension
+	// for the image type if it has none) instead of embedding it in every
+	// track. It must be a plain file name without a directory. Chapters mode
+	// keeps the source's pictures either way.
+	ExtractCoverTo string
+
+	// WritePlaylist writes an "<album>.m3u8" playlist of the tracks with their
+	// durations and titles (not in chapters mode)
+	WritePlaylist bool
+
+	// PlaylistFormat selects the playlist extension (empty means PlaylistM3U8)
+	PlaylistFormat PlaylistFormat
+
+	// WriteTracksCue writes an "<album>.cue" sheet next to the tracks with one
+	// FILE entry per track, so the split album can be re-imported as a
+	// whole (not in chapters mode)
+	WriteTracksCue bool
+
+	// MinTrackDuration, if positive, treats tracks shorter than it as
+	// spurious, like the sub-second tracks some CUE sheet generators leave
+	// between real ones; ShortTracks decides what happens to them. The last
+	// track is only checked when the source duration can be probed.
+	MinTrackDuration time.Duration
+	ShortTracks      ShortTrackMode
+
+	// Tracks limits the output to the selected track numbers (nil = all)
+	Tracks TrackSelection
+
+	// OutputFormat selects FLAC or WAV tracks (empty means OutputFLAC)
+	OutputFormat OutputFormat
+
+	// BlockSize is the pure Go encoder block size in samples (0 = DefaultBlockSize)
+	BlockSize int
+
+	// NonSubset allows a BlockSize outside the FLAC streamable subset (above
+	// 4608 samples up to 48 kHz, 16384 above), which some players cannot decode
+	NonSubset bool
+
+	// PaddingBytes is the size of the PADDING block written after the tags of
+	// every FLAC output, leaving room to edit them in place later (0 = none)
+	PaddingBytes int
+
+	// PregapMode controls PREGAP/POSTGAP handling (default PregapIgnore)
+	PregapMode PregapMode
+
+	// Normalize rescales the decoded album before encoding, with a single gain
+	// for all tracks (pure Go mode only, default NormalizeOff). This changes
+	// the audio and drops ReplayGain values from the CUE.
+	Normalize NormalizeMode
+
+	// NormalizeTarget is the peak in dBFS or loudness in LUFS to normalize to
+	// (0 = DefaultPeakTarget or DefaultLoudnessTarget)
+	NormalizeTarget float64
+
+	// BoundaryMode decides whether INDEX 00 gaps end the previous track, start
+	// the next one or are dropped (default BoundaryIndex01)
+	BoundaryMode BoundaryMode
+
+	// HiddenTrack controls audio before the first INDEX 01 (default HiddenTrackDiscard)
+	HiddenTrack HiddenTrackMode
+
+	// OpenReader and NewWriter replace the mewkiz/flac decoder and encoder
+	// used in pure Go mode (nil = OpenFlacReader and NewFlacWriter)
+	OpenReader OpenReaderFunc
+	NewWriter  NewWriterFunc
+
+	// TrackConcurrency caps how many tracks of an album pure Go mode encodes
+	// at once (0 or 1 = one after another); NewWriter must then be safe to
+	// call from several goroutines.
+	TrackConcurrency int
+
+	// MaxDecodeBytes, if not 0, makes pure Go mode refuse with
+	// ErrDecodeTooLarge, before decoding, a source whose decoded samples
+	// would take more memory than this (4 bytes per sample and channel)
+	MaxDecodeBytes uint64
+
+	// DecodeConcurrency caps how many goroutines pure Go mode decodes the
+	// source FLAC with (0 or 1 = sequentially). The work is split at seek
+	// points, so files without a seek table are still decoded sequentially;
+	// it has no effect with a custom OpenReader.
+	DecodeConcurrency int
+
+	// TrackHook, if set, is called with every track file once it is written
+	// and tagged, e.g. to upload or transcode it; with TrackConcurrency it
+	// may be called from several goroutines. A failed hook is logged, unless
+	// TrackHookFatal makes it fail the album. It is not called in chapters
+	// mode or by SplitStream.
+	TrackHook      TrackHookFunc
+	TrackHookFatal bool
+
+	// Progress, if set, is called periodically during pure Go decoding and
+	// encoding. It may be called from multiple goroutines and must be safe
+	// for concurrent use.
+	Progress ProgressFunc
+
+	// outputNames replaces trackOutputPath for tracks renamed by
+	// resolveOutputNames
+	outputNames map[trackKey]string
+
+	// length is the source length shared by the steps of one Split
+	length *audioLength
+}
+
+// ProgressFunc receives the number of processed units out of total
+type ProgressFunc func(current, total uint64)
+
+// Version is the flac-splitter version, set at build time with
+// -ldflags "-X github.com/ldmonster/flac-splitter/internal/flacsplitter.Version=..."
+var Version = "dev"
+
+// DefaultVendorString returns the vendor string DefaultOptions stamps into
+// tagged files, the tool's name and version
+func DefaultVendorString() string {
+	return "flac-splitter " + Version
 }
 
+// VariousArtistsName is the ALBUMARTIST value used for compilations
+const VariousArtistsName = "Various Artists"
+
+// ErrInvalidTimecode is returned for malformed CUE timecodes in strict mode
+var ErrInvalidTimecode = errors.New("invalid CUE timecode")
+
+// ErrNoSplitter is returned when no usable external splitter is installed
+var ErrNoSplitter = errors.New("no external splitter found")
+
+// errOutputExists is returned by a trackWriter that keeps an existing file
+// because OverwriteFiles is off
+var errOutputExists = errors.New("output file already exists")
+
+// ErrDuplicateOutput is returned when two tracks of an album would be written
+// to the same file and SplitOptions.RenameDuplicates is not set
+var ErrDuplicateOutput = errors.New("duplicate output file")
+
+// ErrUnsupportedInput is returned for source audio the selected mode cannot read
+var ErrUnsupportedInput = errors.New("unsupported input format")
+
 // DefaultOptions returns default split options
 func DefaultOptions(outputDir string) *SplitOptions {
 	return &SplitOptions{
 		OutputDir:       outputDir,
 		FilenamePattern: "%02d - %s.flac",
-		OverwriteFiles:  true,
+		OverwriteFiles:  false,
 		UseFFmpeg:       false,
 		Mode:            ModeGoAudioFull,
+
+		MaxFilenameLength: 255,
+		PaddingBytes:      DefaultPaddingBytes,
+		TagMapping:        DefaultTagMapping(),
+		VendorString:      DefaultVendorString(),
 	}
 }
 
-// Split splits a FLAC file based on CUE sheet using the configured mode
+// Split splits a FLAC file based on CUE sheet using the configured mode.
+// flacPath is used as given, whatever the CUE's FILE entry says; the track
+// layout is still checked against its duration before anything is written.
 func Split(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
+	if err := prepareCue(&cue, opts); err != nil {
+		return err
+	}
+	album := *opts
+	album.length = &audioLength{path: flacPath}
+	opts = &album
+
+	if opts.MinTrackDuration > 0 {
+		var err error
+		if opts, err = filterShortTracks(&cue, flacPath, opts); err != nil {
+			return err
+		}
+	}
+
+	if err := checkGaplessOptions(opts); err != nil {
+		return err
+	}
+	if opts.Tracks != nil {
+		if opts.Mode == ModeChapterize {
+			return fmt.Errorf("track selection is not supported in chapters mode")
+		}
+		selected := 0
+		for _, track := range cue.Tracks {
+			if opts.Tracks.Contains(track.Number) {
+				selected++
+			}
+		}
+		if selected == 0 {
+			log.Printf("  Warning: No tracks match the selection %s", opts.Tracks)
+			return nil
+		}
+		log.Printf("  Splitting %d of %d tracks (selection %s)", selected, len(cue.Tracks), opts.Tracks)
+	}
+
+	switch opts.OutputFormat {
+	case "", OutputFLAC:
+	case OutputWAV:
+		if opts.Mode != ModeGoAudioFull {
+			return fmt.Errorf("WAV output is only available in pure Go mode")
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", opts.OutputFormat)
+	}
+
+	if err := validateNumberFormats(opts); err != nil {
+		return err
+	}
+	if err := opts.FilenameRules.Validate(); err != nil {
+		return err
+	}
+
+	switch opts.PlaylistFormat {
+	case "", PlaylistM3U8, PlaylistM3U:
+	default:
+		return fmt.Errorf("unknown playlist format %q", opts.PlaylistFormat)
+	}
+	if opts.ExtractCoverTo != "" && !validCoverName(opts.ExtractCoverTo) {
+		return fmt.Errorf("cover file name %q must not contain a path", opts.ExtractCoverTo)
+	}
+
+	if opts.Normalize != NormalizeOff && opts.Mode != ModeGoAudioFull {
+		return fmt.Errorf("normalization is only available in pure Go mode")
+	}
+	if opts.Fingerprint {
+		if opts.Mode == ModeChapterize {
+			return fmt.Errorf("fingerprinting is not supported in chapters mode")
+		}
+		if !executableExists(fpcalcCommand) {
+			return fmt.Errorf("fingerprinting needs %s from Chromaprint - please install it", fpcalcCommand)
+		}
+	}
+
+	format := detectAudioFormat(flacPath)
+	if format != FormatFLAC && opts.Mode == ModeGoAudioFull && opts.FallbackToExternal && pureGoFeature(cue, opts) == "" {
+		log.Printf("  Pure Go mode only supports FLAC (detected %s), splitting with external tools", format)
+		external := *opts
+		external.Mode = ModeExternalTools
+		opts = &external
+	}
+	if format != FormatFLAC && (opts.Mode == ModeGoAudioFull || opts.Mode == ModeChapterize) {
+		return fmt.Errorf("%w: pure Go mode only supports FLAC (detected %s); use --external or --hybrid", ErrUnsupportedInput, format)
+	}
+	if format == FormatFLAC {
+		fillFromID3(&cue, flacPath)
+	}
+
+	opts = discOptions(cue, opts)
+	if err := resolveOutputNames(cue, opts); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if !opts.SkipSpaceCheck {
+		if err := checkOutputSpace(flacPath, opts); err != nil {
+			return err
+		}
+	}
+
+	if err := splitWithMode(cue, flacPath, opts); err != nil {
+		return err
+	}
+	if opts.WritePlaylist && opts.Mode != ModeChapterize {
+		if err := writePlaylist(cue, flacPath, opts); err != nil {
+			return err
+		}
+	}
+	if opts.WriteTracksCue && opts.Mode != ModeChapterize {
+		if err := writeTracksCue(cue, flacPath, opts); err != nil {
+			return err
+		}
+	}
+	if opts.ExtractCoverTo != "" {
+		if err := extractCover(flacPath, opts); err != nil {
+			return err
+		}
+	}
+	if opts.WriteManifest {
+		return writeManifest(cue, flacPath, opts)
+	}
+	return nil
+}
+
+// fallbackToExternal retries an album pure Go mode failed to split with an
+// external splitter, as SplitOptions.FallbackToExternal asks. cause is
+// returned when the options need pure Go mode or no splitter is installed.
+func fallbackToExternal(cue cueparser.CueFile, flacPath string, opts *SplitOptions, cause error) error {
+	if feature := pureGoFeature(cue, opts); feature != "" {
+		log.Printf("  Not falling back to external tools: %s needs pure Go mode", feature)
+		return cause
+	}
+
+	log.Printf("  Warning: Pure Go split failed (%v), retrying with external tools", cause)
+	external := *opts
+	external.Mode = ModeExternalTools
+	err := splitWithExternalTools(cue, flacPath, &external)
+	if errors.Is(err, ErrNoSplitter) {
+		log.Printf("  No external splitter is installed to fall back to")
+		return cause
+	}
+	return err
+}
+
+// pureGoFeature names an option set in opts that external splitters would
+// ignore for cue, or returns "" when the album can be split externally
+func pureGoFeature(cue cueparser.CueFile, opts *SplitOptions) string {
+	switch {
+	case opts.OutputFormat == OutputWAV:
+		return "WAV output"
+	case opts.Normalize != NormalizeOff:
+		return "normalization"
+	case opts.Gapless:
+		return "gapless verification"
+	case opts.PregapMode == PregapInsertSilence:
+		return "inserting PREGAP/POSTGAP silence"
+	}
+
+	if opts.HiddenTrack != HiddenTrackDiscard && len(cue.Tracks) > 0 {
+		if start, err := parseCueTime(trackStart(cue.Tracks[0], opts.BoundaryMode), false); err == nil && start > 0 {
+			return "keeping the hidden track"
+		}
+	}
+	return ""
+}
+
+// splitWithMode runs the splitter selected by opts.Mode
+func splitWithMode(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
 	switch opts.Mode {
 	case ModeGoAudio:
 		// Hybrid: Go validation + external tools for splitting
@@ -62,41 +524,475 @@ func Split(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
 
 	case ModeGoAudioFull:
 		// Pure Go: decode, split, and re-encode with Go libraries
-		return SplitWithGoAudio(cue, flacPath, opts)
+		err := SplitWithGoAudio(cue, flacPath, opts)
+		if err != nil && opts.FallbackToExternal && !errors.Is(err, errTrackHook) {
+			return fallbackToExternal(cue, flacPath, opts, err)
+		}
+		return err
 
 	case ModeExternalTools:
 		// External tools only (shnsplit or ffmpeg)
 		return splitWithExternalTools(cue, flacPath, opts)
 
+	case ModeChapterize:
+		// Single output file with chapter markers
+		return SplitWithChapters(cue, flacPath, opts)
+
 	default:
 		return fmt.Errorf("unknown split mode: %d", opts.Mode)
 	}
 }
 
-// sanitizeFilename removes or replaces invalid characters from filenames
-func sanitizeFilename(name string) string {
-	// Replace invalid characters
-	invalid := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|"}
-	result := name
-	for _, char := range invalid {
-		result = strings.ReplaceAll(result, char, "_")
+// prepareCue validates the timecodes of cue in strict mode and drops its
+// data tracks, which have no audio to split. It also checks the options
+// shared by every mode.
+func prepareCue(cue *cueparser.CueFile, opts *SplitOptions) error {
+	if err := validatePadding(opts); err != nil {
+		return err
+	}
+	// Even lenient parsing must understand every timecode: a track whose
+	// index cannot be read would otherwise be cut at the start of the file
+	if err := validateTimecodes(*cue, opts.StrictTimecodes); err != nil {
+		return err
+	}
+
+	// Data tracks of mixed-mode discs have no audio to split
+	if audio := cue.AudioTracks(); len(audio) < len(cue.Tracks) {
+		log.Printf("  Skipping %d data track(s)", len(cue.Tracks)-len(audio))
+		cue.Tracks = audio
+	}
+	return nil
+}
+
+// DiscFolderName returns the subfolder used by SplitOptions.DiscSubfolder
+// for the disc of cue, or "" when the CUE sheet has no disc number
+func DiscFolderName(cue cueparser.CueFile) string {
+	if cue.DiscNumber == "" {
+		return ""
+	}
+	return "Disc " + cue.DiscNumber
+}
+
+// discOptions returns opts with the {disc} filename token expanded and, with
+// DiscSubfolder, OutputDir pointing at the disc folder. Tracks of different
+// discs therefore never share a path. On discs with track numbers of three
+// or more digits %02d is widened to match, so the files still sort by name.
+func discOptions(cue cueparser.CueFile, opts *SplitOptions) *SplitOptions {
+	disc := cue.DiscNumber
+	if disc == "" {
+		disc = "1"
+	}
+
+	o := *opts
+	o.FilenamePattern = strings.ReplaceAll(o.FilenamePattern, "{disc}", disc)
+	if width := trackNumberWidth(cue); width > 2 {
+		o.FilenamePattern = strings.ReplaceAll(o.FilenamePattern, "%02d", fmt.Sprintf("%%0%dd", width))
+	}
+	if folder := DiscFolderName(cue); o.DiscSubfolder && folder != "" {
+		o.OutputDir = filepath.Join(o.OutputDir, folder)
+	}
+	return &o
+}
+
+// PlannedOutputs returns the paths Split would write for cue, so callers
+// can detect albums that would overwrite each other before splitting.
+// Duplicate paths are renamed as Split would with RenameDuplicates.
+func PlannedOutputs(cue cueparser.CueFile, flacPath string, opts *SplitOptions) []string {
+	cue.Tracks = cue.AudioTracks()
+	opts = discOptions(cue, opts)
+	if opts.RenameDuplicates {
+		resolveOutputNames(cue, opts)
+	}
+	return plannedOutputs(cue, flacPath, opts)
+}
+
+// plannedOutputs lists the output paths for cue with already expanded options
+func plannedOutputs(cue cueparser.CueFile, flacPath string, opts *SplitOptions) []string {
+	if opts.Mode == ModeChapterize {
+		return []string{filepath.Join(opts.OutputDir, chapterFilename(cue, flacPath, opts))}
+	}
+
+	var paths []string
+	for _, track := range plannedTracks(cue, opts) {
+		paths = append(paths, trackOutputPath(track, opts))
+	}
+	return paths
+}
+
+// plannedTracks returns the tracks written as separate files for cue
+func plannedTracks(cue cueparser.CueFile, opts *SplitOptions) []cueparser.Track {
+	// A separate hidden track is only written when track 1 starts after 0
+	tracks := cue.Tracks
+	if opts.Mode == ModeGoAudioFull && opts.HiddenTrack == HiddenTrackSeparate && len(tracks) > 0 {
+		if start, err := parseCueTime(trackStart(tracks[0], opts.BoundaryMode), false); err == nil && start > 0 {
+			tracks = append([]cueparser.Track{hiddenTrack(cue)}, tracks...)
+		}
+	}
+
+	var selected []cueparser.Track
+	for _, track := range tracks {
+		if opts.Tracks.Contains(track.Number) {
+			selected = append(selected, track)
+		}
+	}
+	return selected
+}
+
+// resolveOutputNames finds tracks of cue that would be written to the same
+// file, comparing names as outputPathKey does.
+// With opts.RenameDuplicates every later track of a clash gets " (2)", " (3)"
+// and so on appended to its name; otherwise an ErrDuplicateOutput error
+// names the first clash. opts must be a per-album copy from discOptions.
+func resolveOutputNames(cue cueparser.CueFile, opts *SplitOptions) error {
+	if opts.Mode == ModeChapterize {
+		return nil
+	}
+
+	used := make(map[string]int) // outputPathKey -> track number writing it
+	renamed := make(map[trackKey]string)
+	for _, track := range plannedTracks(cue, opts) {
+		path := trackOutputPath(track, opts)
+		key := outputPathKey(path)
+		first, clash := used[key]
+		if clash && !opts.RenameDuplicates {
+			return fmt.Errorf("%w: tracks %d and %d would both be written to %s",
+				ErrDuplicateOutput, first, track.Number, path)
+		}
+
+		if clash {
+			ext := filepath.Ext(path)
+			base := strings.TrimSuffix(path, ext)
+			for n := 2; clash; n++ {
+				path = fmt.Sprintf("%s (%d)%s", base, n, ext)
+				key = outputPathKey(path)
+				_, clash = used[key]
+			}
+			renamed[keyOf(track)] = path
+			log.Printf("  Warning: Track %d shares its file name with an earlier track, writing it as %s",
+				track.Number, filepath.Base(path))
+		}
+		used[key] = track.Number
+	}
+
+	opts.outputNames = renamed
+	return nil
+}
+
+// caseInsensitivePaths is set on Windows and macOS, whose default file
+// systems treat names that differ only in case as the same file
+var caseInsensitivePaths = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// outputPathKey returns the key under which two output paths are the same
+// file: the path itself, lower-cased where caseInsensitivePaths is set
+func outputPathKey(path string) string {
+	if caseInsensitivePaths {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// trackKey identifies a track of an album even when its number is repeated
+type trackKey struct {
+	number int
+	index  string
+}
+
+func keyOf(track cueparser.Track) trackKey {
+	return trackKey{number: track.Number, index: track.Index}
+}
+
+// Source audio formats recognized by detectAudioFormat
+const (
+	FormatFLAC    = "flac"
+	FormatWAV     = "wav"
+	FormatAPE     = "ape"
+	FormatWavPack = "wavpack"
+	FormatALAC    = "alac"
+	FormatUnknown = "unknown"
+)
+
+// detectAudioFormat identifies the source audio format from its magic bytes,
+// falling back to the file extension
+func detectAudioFormat(path string) string {
+	if file, err := os.Open(path); err == nil {
+		defer file.Close()
+
+		header := make([]byte, 12)
+		if n, _ := io.ReadFull(file, header); n == len(header) {
+			switch {
+			case bytes.HasPrefix(header, []byte("fLaC")), bytes.HasPrefix(header, []byte("ID3")):
+				// ID3v2 tags may precede the fLaC marker
+				if strings.ToLower(filepath.Ext(path)) == ".flac" || bytes.HasPrefix(header, []byte("fLaC")) {
+					return FormatFLAC
+				}
+			case bytes.HasPrefix(header, []byte("MAC ")):
+				return FormatAPE
+			case bytes.HasPrefix(header, []byte("wvpk")):
+				return FormatWavPack
+			case bytes.HasPrefix(header, []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+				return FormatWAV
+			case bytes.Equal(header[4:8], []byte("ftyp")):
+				return FormatALAC
+			}
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac":
+		return FormatFLAC
+	case ".wav":
+		return FormatWAV
+	case ".ape":
+		return FormatAPE
+	case ".wv":
+		return FormatWavPack
+	case ".m4a", ".alac":
+		return FormatALAC
+	default:
+		return FormatUnknown
+	}
+}
+
+// windowsReservedNames are device names that cannot be used as filenames on Windows
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// invalidFilenameChars are replaced or stripped by SanitizeFilename
+const invalidFilenameChars = `/\:*?"<>|`
+
+// FilenameRules controls how invalid filename characters in titles and
+// album names are handled. The zero value replaces each with "_".
+type FilenameRules struct {
+	// Replacement replaces invalid characters (empty means "_")
+	Replacement string
+
+	// Strip removes invalid characters instead of replacing them
+	Strip bool
+
+	// Map replaces individual characters, invalid or not, before the rules
+	// above apply, e.g. ':' -> " -" and '/' -> "-" as EAC names files
+	Map map[rune]string
+}
+
+// Validate rejects replacements that would themselves put invalid or control
+// characters into a filename
+func (r FilenameRules) Validate() error {
+	check := func(replacement string) error {
+		for _, c := range replacement {
+			if strings.ContainsRune(invalidFilenameChars, c) || unicode.IsControl(c) {
+				return fmt.Errorf("filename replacement %q contains the invalid character %q", replacement, c)
+			}
+		}
+		return nil
+	}
+
+	if err := check(r.Replacement); err != nil {
+		return err
+	}
+	for _, replacement := range r.Map {
+		if err := check(replacement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sanitize removes or replaces invalid characters from filenames
+func (r FilenameRules) Sanitize(name string) string {
+	replacement := r.Replacement
+	if replacement == "" {
+		replacement = "_"
+	}
+
+	var b strings.Builder
+	for _, c := range name {
+		switch mapped, ok := r.Map[c]; {
+		case ok:
+			b.WriteString(mapped)
+		case strings.ContainsRune(invalidFilenameChars, c):
+			if !r.Strip {
+				b.WriteString(replacement)
+			}
+		case unicode.IsControl(c):
+			// Remove control characters
+		default:
+			b.WriteRune(c)
+		}
+	}
+	result := b.String()
+
+	// Collapse whitespace and strip leading/trailing dots (illegal on Windows)
+	result = strings.Join(strings.Fields(result), " ")
+	result = strings.Trim(result, ". ")
+
+	// Avoid Windows device names such as CON or NUL.txt
+	base := strings.ToUpper(strings.SplitN(result, ".", 2)[0])
+	if windowsReservedNames[base] {
+		result = "_" + result
+	}
+
+	return result
+}
+
+// SanitizeFilename removes or replaces invalid characters from filenames
+// with the default FilenameRules
+func SanitizeFilename(name string) string {
+	return FilenameRules{}.Sanitize(name)
+}
+
+// trackNumberWidth returns the number of digits of the highest track number
+// of cue, or of the track count if that is higher
+func trackNumberWidth(cue cueparser.CueFile) int {
+	highest := len(cue.Tracks)
+	for _, track := range cue.Tracks {
+		highest = max(highest, track.Number)
+	}
+	return len(strconv.Itoa(highest))
+}
+
+// truncateUTF8 shortens s to at most maxBytes without splitting a multibyte rune
+func truncateUTF8(s string, maxBytes int) string {
+	if maxBytes <= 0 {
+		return ""
+	}
+	if len(s) <= maxBytes {
+		return s
+	}
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+	return s[:maxBytes]
+}
+
+// trackOutputPath returns the output file path for a track, shortening the
+// title so the filename fits within MaxFilenameLength
+func trackOutputPath(track cueparser.Track, opts *SplitOptions) string {
+	if path, ok := opts.outputNames[keyOf(track)]; ok {
+		return path
+	}
+
+	render := func(title string) string {
+		name := fmt.Sprintf(opts.FilenamePattern, track.Number, title)
+		if opts.OutputFormat == OutputWAV {
+			name = strings.TrimSuffix(name, filepath.Ext(name)) + ".wav"
+		}
+		return name
+	}
+
+	title := opts.FilenameRules.Sanitize(track.Title)
+	name := render(title)
+
+	if opts.MaxFilenameLength > 0 && len(name) > opts.MaxFilenameLength {
+		excess := len(name) - opts.MaxFilenameLength
+		title = strings.TrimRight(truncateUTF8(title, len(title)-excess), ". ")
+		name = render(title)
+	}
+
+	return filepath.Join(opts.OutputDir, name)
+}
+
+// validateTimecodes checks every track index against the CUE time format.
+// In strict mode out-of-range fields are errors too.
+func validateTimecodes(cue cueparser.CueFile, strict bool) error {
+	if problems := timecodeProblems(cue, strict); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// timecodeProblems returns an error for every timecode of cue that
+// parseCueTime rejects in the given mode
+func timecodeProblems(cue cueparser.CueFile, strict bool) []error {
+	var problems []error
+	check := func(track cueparser.Track, what, cueTime string) {
+		if _, err := parseCueTime(cueTime, strict); err != nil {
+			problems = append(problems, fmt.Errorf("track %d %s: %w", track.Number, what, err))
+		}
+	}
+
+	for _, track := range cue.Tracks {
+		check(track, "INDEX 01", track.Index)
+		if track.PreGap != "" {
+			check(track, "INDEX 00", track.PreGap)
+		}
+		if track.PregapSilence != "" {
+			check(track, "PREGAP", track.PregapSilence)
+		}
+		if track.PostgapSilence != "" {
+			check(track, "POSTGAP", track.PostgapSilence)
+		}
+	}
+	return problems
+}
+
+// parseCueTime parses CUE time format (MM:SS:FF, 75 frames per second), the
+// HH:MM:SS:FF form some tools write for very long files, or the alternate
+// MM:SS.mmm form into seconds. In strict mode out-of-range fields are
+// rejected; otherwise frame overflow is renormalized into seconds.
+func parseCueTime(cueTime string, strict bool) (float64, error) {
+	invalid := func(reason string) (float64, error) {
+		return 0, fmt.Errorf("%w %q: %s", ErrInvalidTimecode, cueTime, reason)
+	}
+
+	parts := strings.Split(strings.TrimSpace(cueTime), ":")
+	switch len(parts) {
+	case 2:
+		// MM:SS.mmm
+		minutes, err := strconv.Atoi(parts[0])
+		if err != nil || minutes < 0 {
+			return invalid("bad minutes field")
+		}
+		seconds, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || seconds < 0 {
+			return invalid("bad seconds field")
+		}
+		if strict && seconds >= 60 {
+			return invalid("seconds field must be below 60")
+		}
+		return float64(minutes*60) + seconds, nil
+
+	case 3, 4:
+		// MM:SS:FF or HH:MM:SS:FF
+		fields := make([]int, len(parts))
+		for i, part := range parts {
+			value, err := strconv.Atoi(part)
+			if err != nil || value < 0 {
+				return invalid("fields must be non-negative integers")
+			}
+			fields[i] = value
+		}
+		minutes, seconds, frames := fields[len(fields)-3], fields[len(fields)-2], fields[len(fields)-1]
+		if len(fields) == 4 {
+			if strict && minutes > 59 {
+				return invalid("minutes field must be 0-59 after an hours field")
+			}
+			minutes += fields[0] * 60
+		}
+		if strict && seconds > 59 {
+			return invalid("seconds field must be 0-59")
+		}
+		if strict && frames > 74 {
+			return invalid("frame field must be 0-74")
+		}
+		// Overflowing frames carry into seconds naturally
+		return float64(minutes*60) + float64(seconds) + float64(frames)/75.0, nil
+
+	default:
+		return invalid("expected MM:SS:FF, HH:MM:SS:FF or MM:SS.mmm")
 	}
-	return strings.TrimSpace(result)
 }
 
 // convertCueTimeToSeconds converts CUE time format (MM:SS:FF) to seconds string
 func convertCueTimeToSeconds(cueTime string) string {
-	parts := strings.Split(cueTime, ":")
-	if len(parts) != 3 {
+	totalSeconds, err := parseCueTime(cueTime, false)
+	if err != nil {
 		return "0"
 	}
-
-	var minutes, seconds, frames int
-	fmt.Sscanf(parts[0], "%d", &minutes)
-	fmt.Sscanf(parts[1], "%d", &seconds)
-	fmt.Sscanf(parts[2], "%d", &frames)
-
-	totalSeconds := float64(minutes*60) + float64(seconds) + float64(frames)/75.0
 	return fmt.Sprintf("%.3f", totalSeconds)
 }
 
@@ -15,14 +15,18 @@
 package flacsplitter
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/go-flac/flacvorbis"
 	"github.com/ldmonster/flac-splitter/internal/cueparser"
-	"github.com/mewkiz/flac"
 	"github.com/mewkiz/flac/frame"
 	"github.com/mewkiz/flac/meta"
 )
@@ -32,38 +36,107 @@ import (
 func SplitWithGoAudio(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
 	log.Printf("  Using pure Go audio libraries for splitting (no external tools)...")
 
+	openReader := opts.OpenReader
+	if openReader == nil {
+		openReader = OpenFlacReader
+	}
+
 	// Open the source FLAC file for decoding
-	stream, err := flac.Open(flacPath)
+	stream, err := openReader(flacPath)
 	if err != nil {
 		return fmt.Errorf("failed to open FLAC file: %v", err)
 	}
 	defer stream.Close()
 
-	// Get stream info
-	info := stream.Info
-	log.Printf("  FLAC Info - Sample Rate: %d Hz, Channels: %d, Bits/Sample: %d",
-		info.SampleRate, info.NChannels, info.BitsPerSample)
+	if opts.DecodeConcurrency > 1 && opts.OpenReader == nil {
+		stream = &seekTableReader{AudioReader: stream, path: flacPath, workers: opts.DecodeConcurrency}
+	}
 
-	// Read all audio samples into memory first
-	log.Printf("  Reading and decoding FLAC audio data...")
-	samples, err := readAllSamples(stream)
+	if err := splitAudio(cue, stream, fileTracks(cue, opts, sourcePictures(flacPath, opts)), opts); err != nil {
+		return err
+	}
+
+	log.Printf("  Split complete with pure Go audio libraries")
+	return nil
+}
+
+// splitAudio decodes stream and hands every track of cue to write
+func splitAudio(cue cueparser.CueFile, stream AudioReader, write trackWriter, opts *SplitOptions) error {
+	return splitDecoded(stream, func(audio *decodedAudio) ([]*encodeJob, error) {
+		return cueJobs(cue, audio, opts)
+	}, write, opts)
+}
+
+// splitDecoded is the pure Go pipeline behind both CUE sheets and
+// SplitByBoundaries: it decodes stream, has plan cut the audio into tracks,
+// normalizes it if asked and hands the tracks to write
+func splitDecoded(stream AudioReader, plan func(audio *decodedAudio) ([]*encodeJob, error),
+	write trackWriter, opts *SplitOptions) error {
+	if err := checkGaplessOptions(opts); err != nil {
+		return err
+	}
+
+	audio, err := decodeAudio(stream, opts)
 	if err != nil {
-		return fmt.Errorf("failed to read FLAC samples: %v", err)
+		return err
+	}
+	if opts.OpenReader == nil {
+		opts.length.decoded(audio.info, audio.total())
 	}
 
-	totalSamples := uint64(len(samples[0]))
-	log.Printf("  Decoded %d samples per channel", totalSamples)
+	jobs, err := plan(audio)
+	if err != nil {
+		return err
+	}
+	if opts.Gapless {
+		// Refuse before encoding anything when the tracks cannot pass
+		ranges := make([]sampleRange, len(jobs))
+		for i, job := range jobs {
+			ranges[i] = job.trackRange
+		}
+		if err := checkCoverage(ranges, audio.total()); err != nil {
+			return fmt.Errorf("gapless verification needs tracks covering all the audio: %w", err)
+		}
+	}
+
+	if opts.Normalize != NormalizeOff {
+		info := audio.info
+		if err := normalizeAlbum(audio.samples, info.SampleRate, info.BitsPerSample, opts.Normalize, opts.NormalizeTarget); err != nil {
+			return err
+		}
+	}
+
+	return encodeTracks(audio, jobs, write, opts)
+}
+
+// cueJobs cuts the decoded audio into the tracks of cue
+func cueJobs(cue cueparser.CueFile, audio *decodedAudio, opts *SplitOptions) ([]*encodeJob, error) {
+	info, totalSamples := audio.info, audio.total()
+
+	// Validate the decoded audio against the CUE layout
+	if err := checkDuration(cue, float64(totalSamples)/float64(info.SampleRate)); err != nil {
+		return nil, err
+	}
+	if opts.Normalize != NormalizeOff {
+		// The CUE's ReplayGain values do not apply to normalized audio
+		cue = withoutReplayGain(cue)
+	}
 
 	// Process each track
-	for i, track := range cue.Tracks {
-		startSample := cueTimeToSample(track.Index, info.SampleRate)
-
-		var endSample uint64
-		if i < len(cue.Tracks)-1 {
-			endSample = cueTimeToSample(cue.Tracks[i+1].Index, info.SampleRate)
-		} else {
-			endSample = totalSamples
+	tracks := cue.Tracks
+	boundaries := CalculateBoundariesWithMode(cue, info.SampleRate, totalSamples, opts.BoundaryMode)
+	if len(boundaries) > 0 && boundaries[0].StartSample > 0 {
+		tracks, boundaries = handleHiddenTrack(cue, boundaries, opts)
+	}
+
+	// Pick the tracks to encode
+	src := trackSource{channels: info.NChannels}
+	var jobs []*encodeJob
+	for i, track := range tracks {
+		if !opts.Tracks.Contains(track.Number) {
+			continue
 		}
+		startSample, endSample := boundaries[i].StartSample, boundaries[i].EndSample
 
 		// Validate sample range
 		if startSample >= totalSamples {
@@ -71,38 +144,272 @@ func SplitWithGoAudio(cue cueparser.CueFile, flacPath string, opts *SplitOptions
 				track.Number, startSample, totalSamples)
 			continue
 		}
-		if endSample > totalSamples {
-			endSample = totalSamples
-		}
+		jobs = append(jobs, &encodeJob{
+			track:      track,
+			trackRange: sampleRange{start: startSample, end: endSample},
+			tag:        trackTags(cue, track, track.Number, src, opts),
+		})
+	}
+	return jobs, nil
+}
+
+// decodedAudio is a source decoded into memory by decodeAudio
+type decodedAudio struct {
+	info      *meta.StreamInfo
+	samples   [][]int32
+	blockSize int // encoder block size, checked against the sample rate
 
-		outputFile := filepath.Join(opts.OutputDir,
-			fmt.Sprintf(opts.FilenamePattern, track.Number, sanitizeFilename(track.Title)))
+	// progressTotal counts decoding followed by encoding, one unit per sample
+	progressTotal uint64
+	progress      func(current, total uint64)
+}
+
+// total returns the number of decoded samples per channel
+func (a *decodedAudio) total() uint64 {
+	return uint64(len(a.samples[0]))
+}
+
+// report passes the progress so far to SplitOptions.Progress
+func (a *decodedAudio) report(current uint64) {
+	if a.progress != nil && a.progressTotal > 0 {
+		a.progress(min(current, a.progressTotal), a.progressTotal)
+	}
+}
 
+// decodeAudio checks the encoder settings and memory limit against stream,
+// then decodes all of it, failing on truncated streams
+func decodeAudio(stream AudioReader, opts *SplitOptions) (*decodedAudio, error) {
+	// Get stream info
+	info := stream.Info()
+	log.Printf("  FLAC Info - Sample Rate: %d Hz, Channels: %d, Bits/Sample: %d",
+		info.SampleRate, info.NChannels, info.BitsPerSample)
+
+	blockSize := opts.BlockSize
+	if blockSize == 0 {
+		blockSize = DefaultBlockSize
+	}
+	if err := validateBlockSize(blockSize, info.SampleRate, opts.NonSubset); err != nil {
+		return nil, err
+	}
+	if err := checkDecodeMemory(info, opts); err != nil {
+		return nil, err
+	}
+
+	audio := &decodedAudio{
+		info:          info,
+		blockSize:     blockSize,
+		progressTotal: 2 * info.NSamples,
+		progress:      opts.Progress,
+	}
+
+	// Read all audio samples into memory first
+	log.Printf("  Reading and decoding FLAC audio data...")
+	samples, err := readAllSamples(stream, audio.report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FLAC samples: %v", err)
+	}
+	audio.samples = samples
+
+	totalSamples := audio.total()
+	log.Printf("  Decoded %d samples per channel", totalSamples)
+	if info.NSamples == 0 {
+		log.Printf("  STREAMINFO does not record the length, using the decoded length %s",
+			FormatDuration(float64(totalSamples)/float64(info.SampleRate)))
+	}
+
+	// Validate the decoded audio against STREAMINFO
+	if info.NSamples != 0 && totalSamples < info.NSamples {
+		return nil, fmt.Errorf("FLAC file is truncated: decoded %d of %d samples (%s missing)",
+			totalSamples, info.NSamples, FormatDuration(float64(info.NSamples-totalSamples)/float64(info.SampleRate)))
+	}
+	if audio.progressTotal == 0 {
+		audio.progressTotal = 2 * totalSamples
+	}
+	return audio, nil
+}
+
+// encodeTracks encodes the sample range of every job from audio and hands it
+// to write along with the job's tags
+func encodeTracks(audio *decodedAudio, jobs []*encodeJob, write trackWriter, opts *SplitOptions) error {
+	newWriter := opts.NewWriter
+	if newWriter == nil {
+		newWriter = NewFlacWriter
+	}
+	info, samples, totalSamples := audio.info, audio.samples, audio.total()
+
+	var encoded atomic.Uint64
+	encodeTrack := func(job *encodeJob) error {
+		track, trackRange := job.track, job.trackRange
 		log.Printf("  Encoding track %d: %s (samples %d-%d)",
-			track.Number, track.Title, startSample, endSample)
+			track.Number, track.Title, trackRange.start, trackRange.end)
+
+		// Encode straight from the decoded buffer, unless silence has to be
+		// added around the track
+		source, sourceRange := samples, trackRange
+		if opts.PregapMode == PregapInsertSilence {
+			pre := cueTimeToSample(track.PregapSilence, info.SampleRate)
+			post := cueTimeToSample(track.PostgapSilence, info.SampleRate)
+			if pre > 0 || post > 0 {
+				log.Printf("  Track %d: inserting %d samples of pregap and %d of postgap silence",
+					track.Number, pre, post)
+				source = padWithSilence(samples, trackRange, pre, post)
+				sourceRange = sampleRange{start: 0, end: uint64(len(source[0]))}
+			}
+		}
+
+		onFrame := func(frameSamples int) {
+			audio.report(totalSamples + encoded.Add(uint64(frameSamples)))
+		}
+
+		encode := func(ws io.WriteSeeker) error {
+			return encodeFlac(ws, source, sourceRange, info, newWriter, audio.blockSize, onFrame)
+		}
+		tag := job.tag
+		if opts.OutputFormat == OutputWAV {
+			// WAV output carries no tags
+			encode = func(ws io.WriteSeeker) error {
+				return encodeWav(ws, source, sourceRange, info, onFrame)
+			}
+			tag = nil
+		}
 
-		// Extract samples for this track
-		trackSamples := extractSampleRange(samples, startSample, endSample)
+		return write(track, encode, tag)
+	}
 
-		// Encode to FLAC
-		if err := encodeFlac(outputFile, trackSamples, info); err != nil {
-			log.Printf("  Warning: Failed to encode track %d: %v", track.Number, err)
+	// Tracks only read the decoded samples and write distinct outputs, so up
+	// to TrackConcurrency of them are encoded at once. Each failure is kept
+	// with its track and reported in album order; the other tracks go on.
+	forEachLimit(len(jobs), opts.TrackConcurrency, func(i int) {
+		jobs[i].err = encodeTrack(jobs[i])
+	})
+
+	var written []sampleRange
+	for _, job := range jobs {
+		if errors.Is(job.err, errOutputExists) {
+			// The existing file stands in for the track in the gapless check
+			log.Printf("  Warning: Skipping track %d, %v", job.track.Number, job.err)
+			written = append(written, job.trackRange)
 			continue
 		}
+		if errors.Is(job.err, errTrackHook) {
+			return job.err
+		}
+		if job.err != nil {
+			log.Printf("  Warning: Failed to encode track %d: %v", job.track.Number, job.err)
+			continue
+		}
+		written = append(written, job.trackRange)
+	}
 
-		// Write metadata tags
-		if err := writeFlacTags(outputFile, cue, track, track.Number); err != nil {
-			log.Printf("  Warning: Failed to write tags for track %d: %v", track.Number, err)
+	if opts.Gapless {
+		if err := verifyGapless(samples, written, info); err != nil {
+			return err
 		}
+		log.Printf("  Gapless check passed: concatenated tracks match the source audio")
 	}
 
-	log.Printf("  Split complete with pure Go audio libraries")
+	audio.report(audio.progressTotal)
 	return nil
 }
 
-// readAllSamples decodes all FLAC frames into sample arrays
-func readAllSamples(stream *flac.Stream) ([][]int32, error) {
-	info := stream.Info
+// encodeJob is one track encoded by encodeTracks, its tags and the outcome of
+// encoding it
+type encodeJob struct {
+	track      cueparser.Track
+	trackRange sampleRange
+	tag        func(cmts *flacvorbis.MetaDataBlockVorbisComment)
+	err        error
+}
+
+// forEachLimit calls fn for every index below n, running at most limit calls
+// at once; a limit below 2 calls fn sequentially in order
+func forEachLimit(n, limit int, fn func(i int)) {
+	if limit < 2 {
+		for i := range n {
+			fn(i)
+		}
+		return
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := range n {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			fn(i)
+		})
+	}
+	wg.Wait()
+}
+
+// HiddenTrackName is the title of the hidden track written by HiddenTrackSeparate
+const HiddenTrackName = "Hidden Track"
+
+// handleHiddenTrack applies opts.HiddenTrack to the audio before the first
+// track's INDEX 01 (HTOA) and returns the tracks and boundaries to encode
+func handleHiddenTrack(cue cueparser.CueFile, boundaries []TrackBoundary, opts *SplitOptions) ([]cueparser.Track, []TrackBoundary) {
+	tracks, layout, mode := layoutHiddenTrack(cue, boundaries, opts)
+
+	hiddenEnd := boundaries[0].StartSample
+	switch mode {
+	case HiddenTrackPrepend:
+		log.Printf("  Prepending %d samples of hidden track audio to track %d", hiddenEnd, cue.Tracks[0].Number)
+	case HiddenTrackSeparate:
+		log.Printf("  Writing %d samples of hidden track audio as track 0", hiddenEnd)
+	default:
+		log.Printf("  Discarding %d samples of hidden track audio before track %d", hiddenEnd, cue.Tracks[0].Number)
+	}
+	return tracks, layout
+}
+
+// layoutHiddenTrack returns the tracks and boundaries that opts.HiddenTrack
+// produces from audio before the first track's INDEX 01, and the mode applied
+func layoutHiddenTrack(cue cueparser.CueFile, boundaries []TrackBoundary, opts *SplitOptions) ([]cueparser.Track, []TrackBoundary, HiddenTrackMode) {
+	mode := opts.HiddenTrack
+	if opts.Gapless && mode == HiddenTrackDiscard {
+		// Keep any audio before the first index so the tracks cover the whole stream
+		mode = HiddenTrackPrepend
+	}
+
+	switch mode {
+	case HiddenTrackPrepend:
+		boundaries = append([]TrackBoundary(nil), boundaries...)
+		boundaries[0].StartSample = 0
+		return cue.Tracks, boundaries, mode
+
+	case HiddenTrackSeparate:
+		tracks := append([]cueparser.Track{hiddenTrack(cue)}, cue.Tracks...)
+		boundaries = append([]TrackBoundary{{StartSample: 0, EndSample: boundaries[0].StartSample}}, boundaries...)
+		return tracks, boundaries, mode
+
+	default:
+		return cue.Tracks, boundaries, mode
+	}
+}
+
+// hiddenTrack returns the track 0 written by HiddenTrackSeparate
+func hiddenTrack(cue cueparser.CueFile) cueparser.Track {
+	return cueparser.Track{
+		Number:       0,
+		Title:        HiddenTrackName,
+		Performer:    cue.Performer,
+		CustomFields: make(map[string]string),
+	}
+}
+
+// readAllSamples decodes all blocks into sample arrays, calling
+// onProgress (if not nil) with the number of samples decoded so far
+func readAllSamples(stream AudioReader, onProgress func(decoded uint64)) ([][]int32, error) {
+	if parallel, ok := stream.(parallelReader); ok {
+		samples, err := parallel.readAll(onProgress)
+		if !errors.Is(err, errNoSeekPoints) {
+			return samples, err
+		}
+		log.Printf("  No usable seek table, decoding sequentially")
+	}
+
+	info := stream.Info()
 	numChannels := int(info.NChannels)
 
 	// Initialize sample arrays for each channel
@@ -113,124 +420,261 @@ func readAllSamples(stream *flac.Stream) ([][]int32, error) {
 
 	// Parse all frames
 	for {
-		frame, err := stream.ParseNext()
+		block, err := stream.ReadBlock()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
 			return nil, fmt.Errorf("failed to parse frame: %w", err)
 		}
+		if len(block) != numChannels {
+			return nil, fmt.Errorf("decoded block has %d channels, want %d", len(block), numChannels)
+		}
 
-		// Append samples from each subframe (channel)
+		// Append samples from each channel
 		for ch := 0; ch < numChannels; ch++ {
-			samples[ch] = append(samples[ch], frame.Subframes[ch].Samples...)
+			samples[ch] = append(samples[ch], block[ch]...)
+		}
+
+		if onProgress != nil {
+			onProgress(uint64(len(samples[0])))
 		}
 	}
 
 	return samples, nil
 }
 
-// extractSampleRange extracts a range of samples from multi-channel sample arrays
-func extractSampleRange(samples [][]int32, start, end uint64) [][]int32 {
-	numChannels := len(samples)
-	extracted := make([][]int32, numChannels)
-
-	for ch := 0; ch < numChannels; ch++ {
-		if end > uint64(len(samples[ch])) {
-			end = uint64(len(samples[ch]))
-		}
-		extracted[ch] = samples[ch][start:end]
+// padWithSilence copies a range of samples into new buffers with pre samples
+// of silence before it and post samples after it
+func padWithSilence(samples [][]int32, r sampleRange, pre, post uint64) [][]int32 {
+	padded := make([][]int32, len(samples))
+	for ch := range samples {
+		buf := make([]int32, pre+(r.end-r.start)+post)
+		copy(buf[pre:], samples[ch][r.start:r.end])
+		padded[ch] = buf
 	}
-
-	return extracted
+	return padded
 }
 
-// encodeFlac encodes samples to a FLAC file
-func encodeFlac(outputPath string, samples [][]int32, info *meta.StreamInfo) error {
-	if len(samples) == 0 || len(samples[0]) == 0 {
+// encodeFlac encodes the given range of the decoded samples as a FLAC stream,
+// calling onFrame (if not nil) with the number of samples in each frame written.
+func encodeFlac(ws io.WriteSeeker, samples [][]int32, r sampleRange, info *meta.StreamInfo, newWriter NewWriterFunc, blockSize int, onFrame func(frameSamples int)) error {
+	if len(samples) == 0 {
 		return fmt.Errorf("no samples to encode")
 	}
-
-	// Create output file
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+	r.end = min(r.end, uint64(len(samples[0])))
+	if r.start >= r.end {
+		return fmt.Errorf("no samples to encode")
 	}
-	defer outFile.Close()
 
 	// Create a new stream info for the output file
 	outputInfo := &meta.StreamInfo{
 		SampleRate:    info.SampleRate,
 		BitsPerSample: info.BitsPerSample,
 		NChannels:     info.NChannels,
-		NSamples:      uint64(len(samples[0])),
+		NSamples:      r.end - r.start,
 	}
 
-	// Create encoder
-	enc, err := flac.NewEncoder(outFile, outputInfo)
+	enc, err := newWriter(ws, outputInfo, blockSize)
 	if err != nil {
-		return fmt.Errorf("failed to create encoder: %w", err)
-	}
-	defer enc.Close()
-
-	// Determine channel mode for header
-	channelMode := frame.ChannelsMono
-	if info.NChannels == 2 {
-		channelMode = frame.ChannelsLR
+		return err
 	}
 
-	// Write samples in frames
-	numChannels := int(info.NChannels)
-	numSamples := len(samples[0])
-	blockSize := 4096 // Standard FLAC block size
-
-	for offset := 0; offset < numSamples; offset += blockSize {
-		// Determine how many samples to write in this frame
-		frameSamples := blockSize
-		if offset+frameSamples > numSamples {
-			frameSamples = numSamples - offset
+	// Write samples in blocks that reference the source buffer directly
+	block := make([][]int32, len(samples))
+	for offset := r.start; offset < r.end; offset += uint64(blockSize) {
+		end := min(offset+uint64(blockSize), r.end)
+		for ch := range block {
+			block[ch] = samples[ch][offset:end]
 		}
-
-		// Create frame with header
-		f := &frame.Frame{
-			Header: frame.Header{
-				HasFixedBlockSize: true,
-				BlockSize:         uint16(frameSamples),
-				SampleRate:        info.SampleRate,
-				Channels:          channelMode,
-				BitsPerSample:     info.BitsPerSample,
-			},
+		if err := enc.WriteBlock(block); err != nil {
+			return err
 		}
 
-		// Create subframes for each channel
-		f.Subframes = make([]*frame.Subframe, numChannels)
-		for ch := 0; ch < numChannels; ch++ {
-			// Extract samples for this channel and frame
-			channelSamples := samples[ch][offset : offset+frameSamples]
-
-			// Create verbatim subframe (uncompressed)
-			f.Subframes[ch] = &frame.Subframe{
-				SubHeader: frame.SubHeader{
-					Pred: frame.PredVerbatim,
-				},
-				Samples:  channelSamples,
-				NSamples: frameSamples,
-			}
+		if onFrame != nil {
+			onFrame(int(end - offset))
 		}
+	}
 
-		// Write the frame
-		if err := enc.WriteFrame(f); err != nil {
-			return fmt.Errorf("failed to write frame: %w", err)
+	return enc.Close()
+}
+
+// writeFileAtomic calls write with a temporary file that only replaces
+// outputPath once write succeeded, so a failed run never leaves a truncated
+// track behind
+func writeFileAtomic(outputPath string, write func(ws io.WriteSeeker) error) error {
+	tmpPath := outputPath + tmpSuffix
+	outFile, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			outFile.Close()
+			os.Remove(tmpPath)
 		}
+	}()
+
+	if err := write(outFile); err != nil {
+		return err
+	}
+
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return fmt.Errorf("failed to move output file into place: %w", err)
 	}
+	committed = true
 
 	return nil
 }
 
-// cueTimeToSample converts CUE time format (MM:SS:FF) to sample number
+// DefaultBlockSize is the encoder block size used when SplitOptions.BlockSize is 0
+const DefaultBlockSize = 4096
+
+// validateBlockSize rejects block sizes FLAC cannot encode, and sizes
+// outside the streamable subset for the sample rate unless nonSubset is set
+func validateBlockSize(blockSize int, sampleRate uint32, nonSubset bool) error {
+	if blockSize < 16 || blockSize > 65535 {
+		return fmt.Errorf("block size %d is outside the FLAC range 16-65535", blockSize)
+	}
+
+	subsetMax := 16384
+	if sampleRate <= 48000 {
+		subsetMax = 4608
+	}
+	if blockSize > subsetMax && !nonSubset {
+		return fmt.Errorf("block size %d exceeds the FLAC subset limit of %d at %d Hz, "+
+			"which some players cannot decode (use --non-subset to allow it)", blockSize, subsetMax, sampleRate)
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written to a file while still allowing
+// the encoder to seek back and rewrite STREAMINFO
+type countingWriter struct {
+	ws io.WriteSeeker
+	n  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ws.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *countingWriter) Seek(offset int64, whence int) (int64, error) {
+	return w.ws.Seek(offset, whence)
+}
+
+// patchStreamInfoSizes overwrites the block and frame size fields of the
+// STREAMINFO block that directly follows the "fLaC" signature
+func patchStreamInfoSizes(ws io.WriteSeeker, blockMin, blockMax uint16, frameMin, frameMax uint32) error {
+	// Skip the signature (4 bytes) and the metadata block header (4 bytes)
+	if _, err := ws.Seek(8, io.SeekStart); err != nil {
+		return err
+	}
+
+	buf := []byte{
+		byte(blockMin >> 8), byte(blockMin),
+		byte(blockMax >> 8), byte(blockMax),
+		byte(frameMin >> 16), byte(frameMin >> 8), byte(frameMin),
+		byte(frameMax >> 16), byte(frameMax >> 8), byte(frameMax),
+	}
+	_, err := ws.Write(buf)
+	return err
+}
+
+// channelAssignment maps a channel count to the FLAC channel assignment
+// using the default channel order from the FLAC specification
+func channelAssignment(nChannels uint8) (frame.Channels, error) {
+	switch nChannels {
+	case 1:
+		return frame.ChannelsMono, nil
+	case 2:
+		return frame.ChannelsLR, nil
+	case 3:
+		return frame.ChannelsLRC, nil
+	case 4:
+		return frame.ChannelsLRLsRs, nil
+	case 5:
+		return frame.ChannelsLRCLsRs, nil
+	case 6:
+		return frame.ChannelsLRCLfeLsRs, nil
+	case 7:
+		return frame.ChannelsLRCLfeCsSlSr, nil
+	case 8:
+		return frame.ChannelsLRCLfeLsRsSlSr, nil
+	default:
+		return 0, fmt.Errorf("unsupported channel count %d (FLAC supports 1-8 channels)", nChannels)
+	}
+}
+
+// channelMasks holds the WAVEFORMATEXTENSIBLE speaker masks matching the
+// channelAssignment layouts, indexed by channel count
+var channelMasks = [...]uint32{
+	1: 0x0004, // FC
+	2: 0x0003, // FL FR
+	3: 0x0007, // FL FR FC
+	4: 0x0033, // FL FR BL BR
+	5: 0x0037, // FL FR FC BL BR
+	6: 0x003F, // FL FR FC LFE BL BR
+	7: 0x070F, // FL FR FC LFE BC SL SR
+	8: 0x063F, // FL FR FC LFE BL BR SL SR
+}
+
+// channelMaskTag returns the WAVEFORMATEXTENSIBLE_CHANNEL_MASK value for a
+// multichannel stream, or "" for mono, stereo and unknown layouts
+func channelMaskTag(nChannels uint8) string {
+	if nChannels <= 2 || int(nChannels) >= len(channelMasks) {
+		return ""
+	}
+	return fmt.Sprintf("0x%04X", channelMasks[nChannels])
+}
+
+// cueTimeToSample converts CUE time format (MM:SS:FF or HH:MM:SS:FF) to sample number. Frames
+// are exactly 1/75 s, so the offset is computed in integers from the raw fields
+// and is sample-exact at any rate; only the MM:SS.mmm form goes through seconds.
 func cueTimeToSample(cueTime string, sampleRate uint32) uint64 {
-	seconds := parseFloat(convertCueTimeToSeconds(cueTime))
-	return uint64(seconds * float64(sampleRate))
+	frames, ok := cueTimeToFrames(cueTime)
+	if !ok {
+		seconds, err := parseCueTime(cueTime, false)
+		if err != nil {
+			return 0
+		}
+		return uint64(seconds * float64(sampleRate))
+	}
+	rate := uint64(sampleRate)
+	return frames/75*rate + frames%75*rate/75
+}
+
+// cueTimeToFrames returns an MM:SS:FF or HH:MM:SS:FF time as a count of CD
+// frames, with overflowing seconds and frames carried like parseCueTime does
+func cueTimeToFrames(cueTime string) (uint64, bool) {
+	parts := strings.Split(strings.TrimSpace(cueTime), ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return 0, false
+	}
+	var minutes uint64
+	for _, part := range parts[:len(parts)-2] {
+		value, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		// An hours field scales the minutes that follow it
+		minutes = minutes*60 + value
+	}
+	var fields [2]uint64
+	for i, part := range parts[len(parts)-2:] {
+		value, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		fields[i] = value
+	}
+	return (minutes*60+fields[0])*75 + fields[1], true
 }
 
 // parseFloat safely parses a float64 from a string
@@ -243,47 +687,45 @@ func parseFloat(s string) float64 {
 // SplitWithGoAudioSimple is a hybrid approach that uses go-audio for validation
 // but still uses external tools for actual splitting
 func SplitWithGoAudioSimple(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
+	if format := detectAudioFormat(flacPath); format != FormatFLAC {
+		log.Printf("  Skipping Go validation for %s input, splitting with external tools...", format)
+		return splitWithExternalTools(cue, flacPath, opts)
+	}
+
 	log.Printf("  Validating FLAC file with go-audio libraries...")
 
-	// Open and validate the FLAC file
-	stream, err := flac.Open(flacPath)
+	// Open and validate the FLAC file; without a length in STREAMINFO this
+	// decodes it to count the samples
+	info, totalSamples, err := sourceLength(flacPath, opts)
 	if err != nil {
 		return fmt.Errorf("failed to open/validate FLAC file: %v", err)
 	}
-	defer stream.Close()
-
-	info := stream.Info
-	log.Printf("  FLAC validated - Sample Rate: %d Hz, Channels: %d, Duration: %.2f seconds",
-		info.SampleRate, info.NChannels, float64(info.NSamples)/float64(info.SampleRate))
+	if info.SampleRate == 0 {
+		return fmt.Errorf("failed to open/validate FLAC file: STREAMINFO has no sample rate")
+	}
+	if info.NSamples == 0 {
+		log.Printf("  STREAMINFO does not record the length, counted %d samples", totalSamples)
+	}
+	log.Printf("  FLAC validated - Sample Rate: %d Hz, Channels: %d, Duration: %s",
+		info.SampleRate, info.NChannels, FormatDuration(float64(totalSamples)/float64(info.SampleRate)))
 
 	// Validate that all tracks fit within the audio duration
-	totalDuration := float64(info.NSamples) / float64(info.SampleRate)
-	for i, track := range cue.Tracks {
-		trackStart := parseFloat(convertCueTimeToSeconds(track.Index))
-
-		if trackStart > totalDuration {
-			return fmt.Errorf("track %d starts at %.2fs but audio is only %.2fs long",
-				track.Number, trackStart, totalDuration)
-		}
-
-		if i < len(cue.Tracks)-1 {
-			trackEnd := parseFloat(convertCueTimeToSeconds(cue.Tracks[i+1].Index))
-			log.Printf("  Track %d: %.2fs - %.2fs (%.2fs)",
-				track.Number, trackStart, trackEnd, trackEnd-trackStart)
-		} else {
-			log.Printf("  Track %d: %.2fs - %.2fs (%.2fs)",
-				track.Number, trackStart, totalDuration, totalDuration-trackStart)
-		}
+	totalDuration := float64(totalSamples) / float64(info.SampleRate)
+	if err := checkDuration(cue, totalDuration); err != nil {
+		return err
 	}
 
-	// Use external tools for actual splitting (validated approach)
-	log.Printf("  Using external tools for actual splitting (after validation)...")
+	for _, b := range CalculateBoundariesWithMode(cue, info.SampleRate, totalSamples, opts.BoundaryMode) {
+		log.Printf("  Track %d: %s - %s (%s)", b.Number, FormatDuration(b.Start), FormatDuration(b.End), FormatDuration(b.Duration))
+	}
 
-	if executableExists("ffmpeg") {
-		return splitWithFFmpeg(cue, flacPath, opts)
-	} else if executableExists("shnsplit") {
-		return splitWithShnsplit(cue, flacPath, opts)
+	// Use external tools for actual splitting (validated approach), chosen
+	// the same way as in external mode
+	splitter, err := selectSplitter(cue, flacPath, opts)
+	if err != nil {
+		return err
 	}
+	log.Printf("  Using %s for actual splitting (after validation)...", splitter.Name)
 
-	return fmt.Errorf("no external tools available for splitting")
+	return splitter.run(cue, flacPath, opts)
 }
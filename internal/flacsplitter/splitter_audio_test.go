@@ -0,0 +1,85 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import "testing"
+
+func TestValidateBlockSize(t *testing.T) {
+	tests := []struct {
+		blockSize  int
+		sampleRate uint32
+		nonSubset  bool
+		wantErr    bool
+	}{
+		{DefaultBlockSize, 44100, false, false},
+		{16, 44100, false, false},
+		{15, 44100, true, true},
+		{65536, 96000, true, true},
+		{4608, 48000, false, false},
+		{4609, 48000, false, true},
+		{4609, 48000, true, false},
+		{16384, 96000, false, false},
+		{16385, 96000, false, true},
+		{65535, 96000, true, false},
+	}
+
+	for _, tt := range tests {
+		err := validateBlockSize(tt.blockSize, tt.sampleRate, tt.nonSubset)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateBlockSize(%d, %d, %t) error = %v, want error %t",
+				tt.blockSize, tt.sampleRate, tt.nonSubset, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSplitRejectsNonSubsetBlockSize(t *testing.T) {
+	cue, flacPath, _ := writeTestAlbum(t, 1,
+		"  TRACK 01 AUDIO", "    INDEX 01 00:00:00",
+	)
+	opts := testOptions(t)
+	opts.BlockSize = 8192
+
+	if err := Split(cue, flacPath, opts); err == nil {
+		t.Fatal("Split() accepted a non-subset block size")
+	}
+
+	opts.NonSubset = true
+	if err := Split(cue, flacPath, opts); err != nil {
+		t.Fatalf("Split() with NonSubset: %v", err)
+	}
+	if tracks := outputTracks(t, opts.OutputDir); len(tracks) != 1 {
+		t.Errorf("got %d tracks, want 1", len(tracks))
+	}
+}
+
+func TestCueTimeToSampleLongTimecodes(t *testing.T) {
+	tests := []struct {
+		cueTime string
+		want    uint64
+	}{
+		{"99:59:74", 5999*44100 + 74*588},
+		{"100:00:00", 6000 * 44100},
+		{"01:40:00:00", 6000 * 44100},
+		{"105:00:12", 6300*44100 + 12*588},
+		{"01:45:00:12", 6300*44100 + 12*588},
+		{"02:00:00:00", 7200 * 44100},
+	}
+
+	for _, tt := range tests {
+		if got := cueTimeToSample(tt.cueTime, 44100); got != tt.want {
+			t.Errorf("cueTimeToSample(%q) = %d, want %d", tt.cueTime, got, tt.want)
+		}
+	}
+}
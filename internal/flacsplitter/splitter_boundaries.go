@@ -0,0 +1,112 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"time"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// TrackBoundary is the position of one track within the source audio
+type TrackBoundary struct {
+	Number      int
+	StartSample uint64 // First sample of the track
+	EndSample   uint64 // One past the last sample of the track
+
+	// The same boundaries in seconds
+	Start    float64
+	End      float64
+	Duration float64
+}
+
+// CalculateBoundaries returns the sample and time boundaries of every track
+// with BoundaryIndex01
+func CalculateBoundaries(cue cueparser.CueFile, sampleRate uint32, totalSamples uint64) []TrackBoundary {
+	return CalculateBoundariesWithMode(cue, sampleRate, totalSamples, BoundaryIndex01)
+}
+
+// CalculateBoundariesWithMode returns the sample and time boundaries of every
+// track. A track runs from its start to the next track's start, where the
+// start is INDEX 01, or INDEX 00 when present and mode is BoundaryIndex00;
+// with BoundaryDropGaps it ends at the next INDEX 00 instead. The last track
+// runs to the end of the audio. Ends are clamped to
+// totalSamples, so a track that starts past the end of the audio gets an
+// empty range.
+func CalculateBoundariesWithMode(cue cueparser.CueFile, sampleRate uint32, totalSamples uint64, mode BoundaryMode) []TrackBoundary {
+	boundaries := make([]TrackBoundary, 0, len(cue.Tracks))
+
+	for i, track := range cue.Tracks {
+		start := cueTimeToSample(trackStart(track, mode), sampleRate)
+
+		end := totalSamples
+		if i < len(cue.Tracks)-1 {
+			end = min(cueTimeToSample(trackEnd(cue.Tracks[i+1], mode), sampleRate), totalSamples)
+		}
+		end = max(end, start)
+
+		b := TrackBoundary{
+			Number:      track.Number,
+			StartSample: start,
+			EndSample:   end,
+		}
+		if sampleRate > 0 {
+			b.Start = float64(start) / float64(sampleRate)
+			b.End = float64(end) / float64(sampleRate)
+			b.Duration = b.End - b.Start
+		}
+		boundaries = append(boundaries, b)
+	}
+
+	return boundaries
+}
+
+// trackStart returns the CUE time a track starts at under mode. An INDEX 00
+// that is missing or not before INDEX 01 is ignored.
+func trackStart(track cueparser.Track, mode BoundaryMode) string {
+	if mode != BoundaryIndex00 || track.PreGap == "" {
+		return track.Index
+	}
+	pregap, err := parseCueTime(track.PreGap, false)
+	if err != nil {
+		return track.Index
+	}
+	if index, err := parseCueTime(track.Index, false); err == nil && pregap >= index {
+		return track.Index
+	}
+	return track.PreGap
+}
+
+// trackEnd returns the CUE time the track before next ends at under mode
+func trackEnd(next cueparser.Track, mode BoundaryMode) string {
+	if mode == BoundaryDropGaps {
+		return trackStart(next, BoundaryIndex00)
+	}
+	return trackStart(next, mode)
+}
+
+// HiddenTrackDuration returns the length of the audio before the first audio
+// track's INDEX 01 (hidden track one audio, HTOA), or 0 when there is none
+func HiddenTrackDuration(cue cueparser.CueFile) time.Duration {
+	tracks := cue.AudioTracks()
+	if len(tracks) == 0 {
+		return 0
+	}
+	seconds, err := parseCueTime(tracks[0].Index, false)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+func TestSplitBoundaryModes(t *testing.T) {
+	// Tracks 2 and 3 have gaps: 1.0s-1.4s and 2.0s-2.2s
+	cue, flacPath, source := writeTestAlbum(t, 3,
+		"  TRACK 01 AUDIO", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    INDEX 00 00:01:00", "    INDEX 01 00:01:30",
+		"  TRACK 03 AUDIO", "    INDEX 00 00:02:00", "    INDEX 01 00:02:15",
+	)
+
+	tests := []struct {
+		name   string
+		mode   BoundaryMode
+		ranges []sampleRange
+	}{
+		{"index01", BoundaryIndex01, []sampleRange{{0, 61740}, {61740, 97020}, {97020, 132300}}},
+		{"index00", BoundaryIndex00, []sampleRange{{0, 44100}, {44100, 88200}, {88200, 132300}}},
+		{"drop gaps", BoundaryDropGaps, []sampleRange{{0, 44100}, {61740, 88200}, {97020, 132300}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions(t)
+			opts.BoundaryMode = tt.mode
+			if err := Split(cue, flacPath, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			tracks := outputTracks(t, opts.OutputDir)
+			if len(tracks) != len(tt.ranges) {
+				t.Fatalf("got %d tracks, want %d", len(tracks), len(tt.ranges))
+			}
+			for i, track := range tracks {
+				samples, _ := readTestFlac(t, track)
+				r := tt.ranges[i]
+				for ch := range samples {
+					if !slices.Equal(samples[ch], source[ch][r.start:r.end]) {
+						t.Errorf("track %d has %d samples, want source samples %d-%d",
+							i+1, len(samples[ch]), r.start, r.end)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateBoundariesWithMode(t *testing.T) {
+	// Tracks 1, 3 and 4 have an INDEX 00; track 2 does not
+	cue := cueparser.CueFile{Tracks: []cueparser.Track{
+		{Number: 1, PreGap: "00:00:00", Index: "00:00:30"},
+		{Number: 2, Index: "00:01:00"},
+		{Number: 3, PreGap: "00:02:00", Index: "00:02:15"},
+		{Number: 4, PreGap: "00:03:00", Index: "00:03:30"},
+	}}
+
+	tests := []struct {
+		name  string
+		mode  BoundaryMode
+		total uint64
+		want  []sampleRange
+	}{
+		{"index01", BoundaryIndex01, 200000,
+			[]sampleRange{{17640, 44100}, {44100, 97020}, {97020, 149940}, {149940, 200000}}},
+		{"index00", BoundaryIndex00, 200000,
+			[]sampleRange{{0, 44100}, {44100, 88200}, {88200, 132300}, {132300, 200000}}},
+		{"drop gaps", BoundaryDropGaps, 200000,
+			[]sampleRange{{17640, 44100}, {44100, 88200}, {97020, 132300}, {149940, 200000}}},
+		{"index01 truncated", BoundaryIndex01, 120000,
+			[]sampleRange{{17640, 44100}, {44100, 97020}, {97020, 120000}, {149940, 149940}}},
+		{"index00 truncated", BoundaryIndex00, 120000,
+			[]sampleRange{{0, 44100}, {44100, 88200}, {88200, 120000}, {132300, 132300}}},
+		{"drop gaps truncated", BoundaryDropGaps, 120000,
+			[]sampleRange{{17640, 44100}, {44100, 88200}, {97020, 120000}, {149940, 149940}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			boundaries := CalculateBoundariesWithMode(cue, testSampleRate, tt.total, tt.mode)
+			var got []sampleRange
+			for _, b := range boundaries {
+				got = append(got, sampleRange{b.StartSample, b.EndSample})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("CalculateBoundariesWithMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateBoundariesIgnoresLateIndex00(t *testing.T) {
+	// An INDEX 00 at or after INDEX 01 is not a gap
+	cue := cueparser.CueFile{Tracks: []cueparser.Track{
+		{Number: 1, Index: "00:00:00"},
+		{Number: 2, PreGap: "00:01:30", Index: "00:01:00"},
+		{Number: 3, PreGap: "00:02:00", Index: "00:02:00"},
+	}}
+	want := []sampleRange{{0, 44100}, {44100, 88200}, {88200, 132300}}
+
+	for _, mode := range []BoundaryMode{BoundaryIndex01, BoundaryIndex00, BoundaryDropGaps} {
+		var got []sampleRange
+		for _, b := range CalculateBoundariesWithMode(cue, testSampleRate, 132300, mode) {
+			got = append(got, sampleRange{b.StartSample, b.EndSample})
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("mode %d: CalculateBoundariesWithMode() = %v, want %v", mode, got, want)
+		}
+	}
+}
+
+func TestCalculateBoundariesLongTimecodes(t *testing.T) {
+	// A 110-minute concert in one file, with minutes past 99 written both as
+	// MM:SS:FF and as HH:MM:SS:FF
+	const total = 110 * 60 * testSampleRate
+	want := []sampleRange{
+		{0, 5999*testSampleRate + 74*588},
+		{5999*testSampleRate + 74*588, 6300*testSampleRate + 12*588},
+		{6300*testSampleRate + 12*588, total},
+	}
+	wantStarts := []float64{0, 5999 + 74.0/75, 6300.16}
+
+	for _, tracks := range [][]cueparser.Track{
+		{
+			{Number: 1, Index: "00:00:00"},
+			{Number: 2, PreGap: "99:58:00", Index: "99:59:74"},
+			{Number: 3, PreGap: "104:58:00", Index: "105:00:12"},
+		},
+		{
+			{Number: 1, Index: "00:00:00:00"},
+			{Number: 2, PreGap: "01:39:58:00", Index: "01:39:59:74"},
+			{Number: 3, PreGap: "01:44:58:00", Index: "01:45:00:12"},
+		},
+	} {
+		cue := cueparser.CueFile{Tracks: tracks}
+		var got []sampleRange
+		for i, b := range CalculateBoundariesWithMode(cue, testSampleRate, total, BoundaryIndex01) {
+			got = append(got, sampleRange{b.StartSample, b.EndSample})
+			if math.Abs(b.Start-wantStarts[i]) > 1e-6 {
+				t.Errorf("track %d (%s) starts at %vs, want %vs", b.Number, tracks[i].Index, b.Start, wantStarts[i])
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("CalculateBoundariesWithMode(%s, ...) = %v, want %v", tracks[2].Index, got, want)
+		}
+
+		got = nil
+		for _, b := range CalculateBoundariesWithMode(cue, testSampleRate, total, BoundaryIndex00) {
+			got = append(got, sampleRange{b.StartSample, b.EndSample})
+		}
+		wantIndex00 := []sampleRange{{0, 5998 * testSampleRate}, {5998 * testSampleRate, 6298 * testSampleRate}, {6298 * testSampleRate, total}}
+		if !slices.Equal(got, wantIndex00) {
+			t.Errorf("CalculateBoundariesWithMode(%s, ..., BoundaryIndex00) = %v, want %v", tracks[2].PreGap, got, wantIndex00)
+		}
+	}
+}
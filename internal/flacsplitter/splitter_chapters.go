@@ -0,0 +1,160 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// SplitWithChapters keeps the source audio intact and writes a single FLAC
+// file with Matroska-style CHAPTERxxx/CHAPTERxxxNAME Vorbis comments
+func SplitWithChapters(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
+	log.Printf("  Writing chapter markers (source audio kept intact)...")
+
+	outputFile := filepath.Join(opts.OutputDir, chapterFilename(cue, flacPath, opts))
+	if same, err := sameFile(outputFile, flacPath); err == nil && same {
+		return fmt.Errorf("chapters output %s would overwrite the source; choose another output directory", outputFile)
+	}
+
+	if !opts.OverwriteFiles {
+		if _, err := os.Stat(outputFile); err == nil {
+			log.Printf("  Warning: Skipping album, output file already exists: %s", outputFile)
+			return nil
+		}
+	}
+
+	duration, err := probeFlacDuration(flacPath, opts)
+	if err != nil {
+		return err
+	}
+	if err := checkDuration(cue, duration); err != nil {
+		return err
+	}
+
+	// The copy only replaces outputFile once it is tagged, so a failed run
+	// leaves nothing behind for the next one to skip
+	if err := writeFileAtomic(outputFile, func(ws io.WriteSeeker) error {
+		return writeChapterFile(ws, flacPath, cue, opts)
+	}); err != nil {
+		return fmt.Errorf("failed to write chapters file %s: %w", outputFile, err)
+	}
+
+	if opts.ChaptersSidecar {
+		sidecar := strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ".chapters.txt"
+		if err := writeChaptersFile(sidecar, cue); err != nil {
+			return fmt.Errorf("failed to write chapters file: %v", err)
+		}
+		log.Printf("  Chapters sidecar written: %s", sidecar)
+	}
+
+	log.Printf("  Chapter markers written for %d tracks", len(cue.Tracks))
+	return nil
+}
+
+// chapterFilename returns the output filename for a chapterized album
+func chapterFilename(cue cueparser.CueFile, flacPath string, opts *SplitOptions) string {
+	if name := opts.FilenameRules.Sanitize(cue.Album); name != "" {
+		if opts.MaxFilenameLength > 0 {
+			name = truncateUTF8(name, opts.MaxFilenameLength-len(".flac"))
+		}
+		return name + ".flac"
+	}
+	return filepath.Base(flacPath)
+}
+
+// writeChapterFile writes the FLAC source at flacPath to w with album tags
+// and one chapter entry per track
+func writeChapterFile(w io.Writer, flacPath string, cue cueparser.CueFile, opts *SplitOptions) error {
+	input, err := os.Open(flacPath)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	if _, err := skipID3v2(input); err != nil {
+		return err
+	}
+	f, err := flac.ParseBytes(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC file: %v", err)
+	}
+	var channels uint8
+	if info, err := f.GetStreamInfo(); err == nil {
+		channels = uint8(info.ChannelCount)
+	}
+
+	if err := retag(f, nil, func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+		addMappedTags(cmts, albumFileValues(cue, channels, opts), cue, nil, opts)
+
+		for i, track := range cue.Tracks {
+			key := fmt.Sprintf("CHAPTER%03d", i)
+			cmts.Add(key, formatChapterTime(track.Index))
+			cmts.Add(key+"NAME", track.Title)
+		}
+	}); err != nil {
+		return err
+	}
+	setPadding(f, opts.PaddingBytes)
+
+	_, err = w.Write(f.Marshal())
+	return err
+}
+
+// albumFileValues returns the tags of a single file holding a whole album
+func albumFileValues(cue cueparser.CueFile, channels uint8, opts *SplitOptions) []tagValue {
+	return append([]tagValue{
+		{FieldTitle, cue.Album},
+		{FieldArtist, cue.Performer},
+		{FieldChannelMask, channelMaskTag(channels)},
+	}, albumTagValues(cue, opts)...)
+}
+
+// writeChaptersFile writes an OGM-style chapters sidecar
+func writeChaptersFile(path string, cue cueparser.CueFile) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for i, track := range cue.Tracks {
+		fmt.Fprintf(writer, "CHAPTER%02d=%s\n", i+1, formatChapterTime(track.Index))
+		fmt.Fprintf(writer, "CHAPTER%02dNAME=%s\n", i+1, track.Title)
+	}
+
+	return writer.Flush()
+}
+
+// formatChapterTime converts CUE time format (MM:SS:FF) to HH:MM:SS.mmm
+func formatChapterTime(cueTime string) string {
+	millis := int64(parseFloat(convertCueTimeToSeconds(cueTime))*1000 + 0.5)
+
+	hours := millis / 3600000
+	minutes := (millis / 60000) % 60
+	seconds := (millis / 1000) % 60
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, millis%1000)
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSplitWithChapters(t *testing.T) {
+	cue, flacPath, source := writeTestAlbum(t, 2,
+		"  TRACK 01 AUDIO", "    TITLE \"One\"", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    TITLE \"Two\"", "    INDEX 01 00:01:00",
+	)
+	opts := testOptions(t)
+	opts.Mode = ModeChapterize
+	if err := Split(cue, flacPath, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(opts.OutputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "Album.flac" {
+		t.Fatalf("output directory holds %v, want only Album.flac", entries)
+	}
+	outputFile := filepath.Join(opts.OutputDir, "Album.flac")
+	samples, _ := readTestFlac(t, outputFile)
+	if samplesMD5(samples) != samplesMD5(source) {
+		t.Error("chapters file does not hold the source audio")
+	}
+	cmts, err := readVorbisComment(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{"CHAPTER001": "00:00:01.000", "CHAPTER001NAME": "Two", "TITLE": "Album"} {
+		if got, _ := cmts.Get(name); !slices.Equal(got, []string{value}) {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestSplitWithChaptersKeepsSource(t *testing.T) {
+	for _, album := range []string{"album", ""} {
+		cue, flacPath, _ := writeTestAlbum(t, 1, "  TRACK 01 AUDIO", "    INDEX 01 00:00:00")
+		cue.Album = album
+		before, err := os.ReadFile(flacPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The output lands on the source itself
+		opts := testOptions(t)
+		opts.Mode = ModeChapterize
+		opts.OutputDir = filepath.Dir(flacPath)
+		opts.OverwriteFiles = true
+		if err := Split(cue, flacPath, opts); err == nil {
+			t.Errorf("album %q: Split() wrote the chapters file over its source", album)
+		}
+		if after, err := os.ReadFile(flacPath); err != nil || !slices.Equal(after, before) {
+			t.Errorf("album %q: source changed from %d to %d bytes (%v)", album, len(before), len(after), err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// isrcPattern matches an ISRC: country, registrant, year and designation code
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+
+// AlbumReport lists what is wrong with a CUE sheet and its audio
+type AlbumReport struct {
+	Problems []string // Errors that would break or corrupt a split
+	Warnings []string // Suspicious but splittable
+}
+
+// CheckAlbum inspects a parsed CUE sheet and its audio file without
+// splitting: it reports malformed timecodes, invalid ISRCs, indexes that do
+// not increase, and for FLAC audio, a duration that does not fit the CUE.
+func CheckAlbum(cue cueparser.CueFile, audioPath string) AlbumReport {
+	var report AlbumReport
+	problem := func(format string, args ...any) {
+		report.Problems = append(report.Problems, fmt.Sprintf(format, args...))
+	}
+
+	for _, err := range timecodeProblems(cue, true) {
+		problem("%v", err)
+	}
+
+	var prevStart float64
+	for i, track := range cue.Tracks {
+		if track.ISRC != "" && !isrcPattern.MatchString(track.ISRC) {
+			problem("track %d has an invalid ISRC %q", track.Number, track.ISRC)
+		}
+
+		index, err := parseCueTime(track.Index, false)
+		if err != nil {
+			continue
+		}
+		if track.PreGap != "" {
+			if pregap, err := parseCueTime(track.PreGap, false); err == nil && pregap > index {
+				problem("track %d INDEX 00 %s is after its INDEX 01 %s", track.Number, track.PreGap, track.Index)
+			}
+		}
+		if i > 0 && index <= prevStart {
+			problem("track %d INDEX 01 %s does not come after track %d", track.Number, track.Index, cue.Tracks[i-1].Number)
+		}
+		prevStart = index
+	}
+
+	if detectAudioFormat(audioPath) != FormatFLAC {
+		return report
+	}
+	seconds, err := probeFlacDuration(audioPath, nil)
+	if err != nil {
+		problem("cannot read audio: %v", err)
+		return report
+	}
+	if seconds == 0 {
+		report.Warnings = append(report.Warnings, "the FLAC file has no audio or sample rate, duration not checked")
+		return report
+	}
+	// Data tracks have no audio in the file, as when splitting
+	cue.Tracks = cue.AudioTracks()
+	warning, err := durationProblems(cue, seconds)
+	if err != nil {
+		problem("%v", err)
+	}
+	if warning != "" {
+		report.Warnings = append(report.Warnings, warning)
+	}
+
+	return report
+}
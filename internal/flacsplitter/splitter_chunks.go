@@ -0,0 +1,70 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// ChunkedCue synthesizes a CUE sheet that cuts a FLAC file into consecutive
+// tracks of the given length, for recordings that come without a CUE. The
+// last track holds whatever remains.
+func ChunkedCue(flacPath string, chunk time.Duration) (cueparser.CueFile, error) {
+	if chunk < time.Second {
+		return cueparser.CueFile{}, fmt.Errorf("chunk length must be at least 1s, got %s", chunk)
+	}
+
+	duration, err := probeFlacDuration(flacPath, nil)
+	if err != nil {
+		return cueparser.CueFile{}, err
+	}
+	if duration == 0 {
+		return cueparser.CueFile{}, fmt.Errorf("%s has no audio", flacPath)
+	}
+
+	name := filepath.Base(flacPath)
+	cue := cueparser.CueFile{
+		Path:          flacPath,
+		RelativePath:  name,
+		FileName:      name,
+		AudioFile:     name,
+		AudioFileType: "WAVE",
+		Album:         strings.TrimSuffix(name, filepath.Ext(name)),
+		CustomFields:  make(map[string]string),
+	}
+
+	for start := 0.0; start < duration; start += chunk.Seconds() {
+		number := len(cue.Tracks) + 1
+		cue.Tracks = append(cue.Tracks, cueparser.Track{
+			Number:       number,
+			Title:        fmt.Sprintf("Part %d", number),
+			Index:        formatCueTime(start),
+			CustomFields: make(map[string]string),
+		})
+	}
+
+	return cue, nil
+}
+
+// formatCueTime converts seconds to CUE time format (MM:SS:FF)
+func formatCueTime(seconds float64) string {
+	frames := int64(seconds*75 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d", frames/(75*60), (frames/75)%60, frames%75)
+}
@@ -0,0 +1,269 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+// AudioReader decodes a source audio stream block by block
+type AudioReader interface {
+	// Info describes the stream; NSamples is 0 when the length is unknown
+	Info() *meta.StreamInfo
+
+	// ReadBlock returns the next block of samples, one slice per channel,
+	// or io.EOF after the last block. The slices may be reused by the next call.
+	ReadBlock() ([][]int32, error)
+
+	Close() error
+}
+
+// AudioWriter encodes the samples of one output track block by block
+type AudioWriter interface {
+	// WriteBlock encodes one block of samples, one slice per channel
+	WriteBlock(samples [][]int32) error
+
+	// Close finishes the stream without closing the underlying file
+	Close() error
+}
+
+// OpenReaderFunc opens the audio file at path for decoding
+type OpenReaderFunc func(path string) (AudioReader, error)
+
+// NewWriterFunc creates an encoder that writes the stream described by info
+// to ws in blocks of at most blockSize samples
+type NewWriterFunc func(ws io.WriteSeeker, info *meta.StreamInfo, blockSize int) (AudioWriter, error)
+
+// OpenFlacReader is the default OpenReaderFunc, decoding FLAC with mewkiz/flac
+func OpenFlacReader(path string) (AudioReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newFlacReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	// The mewkiz stream buffers its reader and never closes the file itself
+	r.closer = file
+	return r, nil
+}
+
+// NewFlacReader decodes the FLAC stream read from r with mewkiz/flac.
+// Closing the returned reader does not close r.
+func NewFlacReader(r io.Reader) (AudioReader, error) {
+	return newFlacReader(r)
+}
+
+func newFlacReader(r io.Reader) (*flacReader, error) {
+	stream, err := flac.New(r)
+	if err != nil {
+		return nil, err
+	}
+	return &flacReader{stream: stream}, nil
+}
+
+// flacReader adapts a mewkiz/flac stream to AudioReader
+type flacReader struct {
+	stream *flac.Stream
+	closer io.Closer
+	block  [][]int32
+}
+
+func (r *flacReader) Info() *meta.StreamInfo {
+	return r.stream.Info
+}
+
+func (r *flacReader) ReadBlock() ([][]int32, error) {
+	f, err := r.stream.ParseNext()
+	if err != nil {
+		return nil, err
+	}
+	r.block = r.block[:0]
+	for _, subframe := range f.Subframes {
+		r.block = append(r.block, subframe.Samples)
+	}
+	return r.block, nil
+}
+
+func (r *flacReader) Close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// NewFlacWriter is the default NewWriterFunc, encoding FLAC frames with
+// mewkiz/flac. Its prediction analysis stores each subframe as constant,
+// fixed (order 0-4, one Rice partition) or verbatim, whichever is smallest;
+// there is no LPC, so files are larger than the reference encoder's. Stereo
+// frames use whichever of left/right, left/side, side/right and mid/side
+// looks smallest. Closing it back-patches STREAMINFO with the sample count,
+// MD5 and the block and frame sizes.
+func NewFlacWriter(ws io.WriteSeeker, info *meta.StreamInfo, blockSize int) (AudioWriter, error) {
+	channelMode, err := channelAssignment(info.NChannels)
+	if err != nil {
+		return nil, err
+	}
+
+	// The counting writer measures each encoded frame and, not being an
+	// io.Closer, keeps the file open after enc.Close
+	counter := &countingWriter{ws: ws}
+	enc, err := flac.NewEncoder(counter, info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create encoder: %w", err)
+	}
+
+	// The frame and its subframes are allocated once; each block only
+	// points them at the next samples
+	f := &frame.Frame{
+		Header: frame.Header{
+			HasFixedBlockSize: true,
+			SampleRate:        info.SampleRate,
+			Channels:          channelMode,
+			BitsPerSample:     info.BitsPerSample,
+		},
+		Subframes: make([]*frame.Subframe, info.NChannels),
+	}
+	for ch := range f.Subframes {
+		f.Subframes[ch] = &frame.Subframe{}
+	}
+
+	// The minimum block size excludes the (possibly shorter) last block, so a
+	// fixed-blocksize stream reports the same value for both
+	fixedBlockSize := uint64(blockSize)
+	if info.NSamples > 0 {
+		fixedBlockSize = min(fixedBlockSize, info.NSamples)
+	}
+
+	w := &flacWriter{
+		enc:       enc,
+		counter:   counter,
+		frame:     f,
+		blockSize: uint16(fixedBlockSize),
+	}
+	// The side channel takes one bit more than the source, which FLAC cannot
+	// store for 32-bit audio
+	if channelMode == frame.ChannelsLR && info.BitsPerSample < 32 {
+		w.stereo = make([][]int32, 2)
+		w.pickStereo = stereoMode
+	}
+	return w, nil
+}
+
+// flacWriter adapts a mewkiz/flac encoder to AudioWriter
+type flacWriter struct {
+	enc     *flac.Encoder
+	counter *countingWriter
+	frame   *frame.Frame
+
+	blockSize          uint16
+	frameMin, frameMax uint32
+
+	// stereo, for stereo streams, holds copies of the samples for the
+	// encoder to decorrelate in place, leaving the caller's buffer untouched
+	stereo [][]int32
+
+	// pickStereo chooses the channel assignment of each stereo block
+	pickStereo func(left, right []int32) frame.Channels
+}
+
+func (w *flacWriter) WriteBlock(samples [][]int32) error {
+	if len(samples) != len(w.frame.Subframes) {
+		return fmt.Errorf("got %d channels, want %d", len(samples), len(w.frame.Subframes))
+	}
+	n := len(samples[0])
+
+	w.frame.Header.BlockSize = uint16(n)
+	if w.stereo != nil {
+		w.frame.Header.Channels = w.pickStereo(samples[0], samples[1])
+		if w.frame.Header.Channels != frame.ChannelsLR {
+			for ch := range w.stereo {
+				w.stereo[ch] = append(w.stereo[ch][:0], samples[ch]...)
+			}
+			samples = w.stereo
+		}
+	}
+	for ch, subframe := range w.frame.Subframes {
+		// The encoder's prediction analysis rewrites the subframe header and
+		// only runs on verbatim subframes, so reset it for every frame
+		subframe.SubHeader = frame.SubHeader{
+			Pred: frame.PredVerbatim,
+		}
+		subframe.Samples = samples[ch]
+		subframe.NSamples = n
+	}
+
+	written := w.counter.n
+	if err := w.enc.WriteFrame(w.frame); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+
+	frameSize := uint32(w.counter.n - written)
+	if w.frameMin == 0 || frameSize < w.frameMin {
+		w.frameMin = frameSize
+	}
+	w.frameMax = max(w.frameMax, frameSize)
+	return nil
+}
+
+func (w *flacWriter) Close() error {
+	// Close back-patches STREAMINFO with the sample count and MD5
+	if err := w.enc.Close(); err != nil {
+		return fmt.Errorf("failed to finalize FLAC stream: %w", err)
+	}
+	if err := patchStreamInfoSizes(w.counter.ws, w.blockSize, w.blockSize, w.frameMin, w.frameMax); err != nil {
+		return fmt.Errorf("failed to update STREAMINFO: %w", err)
+	}
+	return nil
+}
+
+// stereoMode picks the stereo channel assignment that should compress a
+// block best: left/right, left/side, side/right or mid/side. Like the
+// reference encoder, it compares the sums of the second order fixed
+// prediction residuals of each channel the assignments would store.
+func stereoMode(left, right []int32) frame.Channels {
+	var sumLeft, sumRight, sumMid, sumSide int64
+	for i := 2; i < len(left); i++ {
+		l := int64(left[i]) - 2*int64(left[i-1]) + int64(left[i-2])
+		r := int64(right[i]) - 2*int64(right[i-1]) + int64(right[i-2])
+		sumLeft += abs64(l)
+		sumRight += abs64(r)
+		sumMid += abs64((l + r) >> 1)
+		sumSide += abs64(l - r)
+	}
+
+	mode, best := frame.ChannelsLR, sumLeft+sumRight
+	for _, c := range []struct {
+		mode frame.Channels
+		cost int64
+	}{
+		{frame.ChannelsLeftSide, sumLeft + sumSide},
+		{frame.ChannelsSideRight, sumSide + sumRight},
+		{frame.ChannelsMidSide, sumMid + sumSide},
+	} {
+		if c.cost < best {
+			mode, best = c.mode, c.cost
+		}
+	}
+	return mode
+}
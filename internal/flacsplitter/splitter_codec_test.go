@@ -0,0 +1,168 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+// seekDiscard is an io.WriteSeeker that drops everything written to it
+type seekDiscard struct {
+	pos, size int64
+}
+
+func (d *seekDiscard) Write(p []byte) (int, error) {
+	d.pos += int64(len(p))
+	d.size = max(d.size, d.pos)
+	return len(p), nil
+}
+
+func (d *seekDiscard) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += d.pos
+	case io.SeekEnd:
+		offset += d.size
+	}
+	d.pos = offset
+	return offset, nil
+}
+
+func TestFlacWriterStereoModes(t *testing.T) {
+	modes := []struct {
+		name string
+		mode frame.Channels
+	}{
+		{"left/right", frame.ChannelsLR},
+		{"left/side", frame.ChannelsLeftSide},
+		{"side/right", frame.ChannelsSideRight},
+		{"mid/side", frame.ChannelsMidSide},
+	}
+
+	for _, bits := range []uint8{16, 24} {
+		// The last block swings between full scale extremes, where the side
+		// channel needs one bit more than the source
+		samples := testSignal(2, 3*DefaultBlockSize)
+		peak := int32(1)<<(bits-1) - 1
+		for ch := range samples {
+			for i := range samples[ch] {
+				samples[ch][i] <<= bits - 16
+			}
+			for i := 2 * DefaultBlockSize; i < len(samples[ch]); i++ {
+				samples[ch][i] = peak
+				if (i+ch)%2 == 1 {
+					samples[ch][i] = -peak - 1
+				}
+			}
+		}
+		h := md5.New()
+		hashSamples(h, samples, 0, uint64(len(samples[0])), bits)
+		wantMD5 := h.Sum(nil)
+
+		for _, m := range modes {
+			t.Run(fmt.Sprintf("%d-bit %s", bits, m.name), func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "stereo.flac")
+				file, err := os.Create(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer file.Close()
+				info := &meta.StreamInfo{SampleRate: testSampleRate, NChannels: 2, BitsPerSample: bits}
+				w, err := NewFlacWriter(file, info, DefaultBlockSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.(*flacWriter).pickStereo = func(left, right []int32) frame.Channels { return m.mode }
+				for start := 0; start < len(samples[0]); start += DefaultBlockSize {
+					end := start + DefaultBlockSize
+					if err := w.WriteBlock([][]int32{samples[0][start:end], samples[1][start:end]}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				stream, err := flac.ParseFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer stream.Close()
+				if !bytes.Equal(stream.Info.MD5sum[:], wantMD5) {
+					t.Errorf("STREAMINFO MD5 = %x, want %x", stream.Info.MD5sum, wantMD5)
+				}
+				decoded := make([][]int32, 2)
+				for {
+					f, err := stream.ParseNext()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					if f.Channels != m.mode {
+						t.Fatalf("frame %d uses channels %v, want %v", f.Num, f.Channels, m.mode)
+					}
+					for ch, subframe := range f.Subframes {
+						decoded[ch] = append(decoded[ch], subframe.Samples...)
+					}
+				}
+				for ch := range samples {
+					if !slices.Equal(decoded[ch], samples[ch]) {
+						t.Errorf("channel %d does not decode to the encoded samples", ch)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkEncodeFlac(b *testing.B) {
+	samples := testSignal(2, 10*testSampleRate)
+	info := &meta.StreamInfo{
+		SampleRate:    testSampleRate,
+		NChannels:     2,
+		BitsPerSample: 16,
+		NSamples:      uint64(len(samples[0])),
+	}
+	b.SetBytes(int64(len(samples[0]) * len(samples) * 2))
+
+	for b.Loop() {
+		w, err := NewFlacWriter(&seekDiscard{}, info, DefaultBlockSize)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for start := 0; start < len(samples[0]); start += DefaultBlockSize {
+			end := min(start+DefaultBlockSize, len(samples[0]))
+			if err := w.WriteBlock([][]int32{samples[0][start:end], samples[1][start:end]}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
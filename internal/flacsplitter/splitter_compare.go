@@ -0,0 +1,141 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// PCMDifference describes where two FLAC files first differ
+type PCMDifference struct {
+	Sample  uint64 // Sample index per channel of the first difference
+	Channel int    // Channel of the first difference, or -1 if not channel specific
+	Reason  string
+}
+
+func (d *PCMDifference) String() string {
+	if d.Channel < 0 {
+		return fmt.Sprintf("sample %d: %s", d.Sample, d.Reason)
+	}
+	return fmt.Sprintf("sample %d, channel %d: %s", d.Sample, d.Channel, d.Reason)
+}
+
+// CompareFlacPCM decodes the FLAC files a and b and reports whether they hold
+// identical sample data. Use DiffFlacPCM to find out where they differ.
+func CompareFlacPCM(a, b string) (bool, error) {
+	diff, err := DiffFlacPCM(a, b, false)
+	if err != nil {
+		return false, err
+	}
+	return diff == nil, nil
+}
+
+// DiffFlacPCM decodes the FLAC files a and b and returns their first
+// difference, or nil if the sample data is identical. A different channel
+// count always counts as a difference; with compareInfo a different sample
+// rate or bit depth does too.
+func DiffFlacPCM(a, b string, compareInfo bool) (*PCMDifference, error) {
+	ra, err := OpenFlacReader(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", a, err)
+	}
+	defer ra.Close()
+	rb, err := OpenFlacReader(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", b, err)
+	}
+	defer rb.Close()
+
+	ia, ib := ra.Info(), rb.Info()
+	switch {
+	case ia.NChannels != ib.NChannels:
+		return &PCMDifference{Channel: -1, Reason: fmt.Sprintf("%d channels vs %d", ia.NChannels, ib.NChannels)}, nil
+	case compareInfo && ia.SampleRate != ib.SampleRate:
+		return &PCMDifference{Channel: -1, Reason: fmt.Sprintf("sample rate %d Hz vs %d Hz", ia.SampleRate, ib.SampleRate)}, nil
+	case compareInfo && ia.BitsPerSample != ib.BitsPerSample:
+		return &PCMDifference{Channel: -1, Reason: fmt.Sprintf("%d bits per sample vs %d", ia.BitsPerSample, ib.BitsPerSample)}, nil
+	}
+
+	ca, cb := &sampleCursor{r: ra}, &sampleCursor{r: rb}
+	var pos uint64
+	for {
+		okA, err := ca.fill()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", a, err)
+		}
+		okB, err := cb.fill()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", b, err)
+		}
+		if !okA || !okB {
+			if okA != okB {
+				return &PCMDifference{Sample: pos, Channel: -1, Reason: "one file ends here"}, nil
+			}
+			return nil, nil
+		}
+
+		// Compare the overlap of the current blocks in one go
+		n := min(ca.remaining(), cb.remaining())
+		for ch := range ca.block {
+			sa := ca.block[ch][ca.pos : ca.pos+n]
+			sb := cb.block[ch][cb.pos : cb.pos+n]
+			for i := range sa {
+				if sa[i] != sb[i] {
+					return &PCMDifference{
+						Sample:  pos + uint64(i),
+						Channel: ch,
+						Reason:  fmt.Sprintf("%d vs %d", sa[i], sb[i]),
+					}, nil
+				}
+			}
+		}
+		ca.pos += n
+		cb.pos += n
+		pos += uint64(n)
+	}
+}
+
+// sampleCursor walks the decoded blocks of an AudioReader, which may differ
+// in size between the two files being compared
+type sampleCursor struct {
+	r     AudioReader
+	block [][]int32
+	pos   int
+}
+
+// fill makes sure the current block has samples left, reading the next one
+// if needed, and returns false at the end of the stream
+func (c *sampleCursor) fill() (bool, error) {
+	for c.block == nil || c.remaining() == 0 {
+		block, err := c.r.ReadBlock()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		c.block, c.pos = block, 0
+	}
+	return true, nil
+}
+
+func (c *sampleCursor) remaining() int {
+	if len(c.block) == 0 {
+		return 0
+	}
+	return len(c.block[0]) - c.pos
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitAndMergeIsLossless(t *testing.T) {
+	cue, flacPath, _ := writeTestAlbum(t, 2,
+		"  TRACK 01 AUDIO", "    TITLE \"One\"", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    TITLE \"Two\"", "    INDEX 01 00:00:37",
+		"  TRACK 03 AUDIO", "    TITLE \"Three\"", "    INDEX 01 00:01:61",
+	)
+	opts := testOptions(t)
+	if err := Split(cue, flacPath, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	merged := filepath.Join(t.TempDir(), "merged.flac")
+	if _, err := MergeTracks(outputTracks(t, opts.OutputDir), merged, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	same, err := CompareFlacPCM(flacPath, merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !same {
+		diff, _ := DiffFlacPCM(flacPath, merged, true)
+		t.Errorf("merged tracks differ from the source: %v", diff)
+	}
+}
+
+func TestDiffFlacPCM(t *testing.T) {
+	dir := t.TempDir()
+	samples := testSignal(2, 10000)
+	original := filepath.Join(dir, "original.flac")
+	writeTestFlac(t, original, samples)
+
+	changed := concatSamples(samples) // a copy
+	changed[1][6000]++
+	modified := filepath.Join(dir, "modified.flac")
+	writeTestFlac(t, modified, changed)
+
+	short := filepath.Join(dir, "short.flac")
+	writeTestFlac(t, short, [][]int32{samples[0][:9000], samples[1][:9000]})
+
+	tests := []struct {
+		name string
+		b    string
+		want *PCMDifference
+	}{
+		{"identical", original, nil},
+		{"one sample", modified, &PCMDifference{Sample: 6000, Channel: 1}},
+		{"shorter", short, &PCMDifference{Sample: 9000, Channel: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffFlacPCM(original, tt.b, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil || got == nil {
+				if got != tt.want {
+					t.Errorf("DiffFlacPCM() = %v, want %v", got, tt.want)
+				}
+				return
+			}
+			if got.Sample != tt.want.Sample || got.Channel != tt.want.Channel {
+				t.Errorf("DiffFlacPCM() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-flac/go-flac"
+)
+
+// pictureFrontCover is the PICTURE type of a front cover
+const pictureFrontCover = 3
+
+// pictureLinkMIME is the MIME type of a PICTURE block whose data is the URL
+// of the image rather than the image itself
+const pictureLinkMIME = "-->"
+
+// coverExtensions maps image MIME types to the extension added to an
+// ExtractCoverTo name that has none
+var coverExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/jpg":  ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// coverImage is the image carried by a PICTURE metadata block
+type coverImage struct {
+	pictureType uint32
+	mime        string
+	data        []byte
+}
+
+// parsePicture decodes a PICTURE metadata block
+func parsePicture(block *flac.MetaDataBlock) (coverImage, error) {
+	r := bytes.NewReader(block.Data)
+	// readBytes reads a 32-bit length followed by that many bytes
+	readBytes := func() ([]byte, error) {
+		var n uint32
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, err
+		}
+		if int64(n) > int64(r.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+
+	var img coverImage
+	var mime []byte
+	err := binary.Read(r, binary.BigEndian, &img.pictureType)
+	if err == nil {
+		mime, err = readBytes()
+	}
+	if err == nil {
+		_, err = readBytes() // description
+	}
+	if err == nil {
+		// width, height, color depth and palette size
+		_, err = r.Seek(16, io.SeekCurrent)
+	}
+	if err == nil {
+		img.data, err = readBytes()
+	}
+	if err != nil {
+		return coverImage{}, fmt.Errorf("invalid PICTURE block: %w", err)
+	}
+	img.mime = strings.ToLower(string(mime))
+	return img, nil
+}
+
+// frontCover returns the front cover among pictures, or the first picture
+// when none is marked as one. PICTURE blocks that only link to an image
+// (MIME type "-->") are passed over, as are damaged ones with a warning;
+// ok is false when no picture is left.
+func frontCover(pictures []*flac.MetaDataBlock) (img coverImage, ok bool) {
+	for _, block := range pictures {
+		picture, err := parsePicture(block)
+		if err != nil {
+			log.Printf("  Warning: Ignoring embedded picture: %v", err)
+			continue
+		}
+		if picture.mime == pictureLinkMIME {
+			continue
+		}
+		if picture.pictureType == pictureFrontCover {
+			return picture, true
+		}
+		if !ok {
+			img, ok = picture, true
+		}
+	}
+	return img, ok
+}
+
+// validCoverName reports whether name can be used for ExtractCoverTo: a
+// plain file name, so the cover stays in the album output directory
+func validCoverName(name string) bool {
+	return name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// extractCover writes the cover of the FLAC source at audioPath to
+// opts.ExtractCoverTo in the album output directory. Sources without
+// pictures are skipped with a log line, not an error.
+func extractCover(audioPath string, opts *SplitOptions) error {
+	if !validCoverName(opts.ExtractCoverTo) {
+		return fmt.Errorf("cover file name %q must not contain a path", opts.ExtractCoverTo)
+	}
+	if detectAudioFormat(audioPath) != FormatFLAC {
+		return nil
+	}
+	pictures, err := readPictures(audioPath)
+	if err != nil {
+		return fmt.Errorf("failed to read embedded pictures: %w", err)
+	}
+	img, ok := frontCover(pictures)
+	if !ok {
+		log.Printf("  No embedded cover to extract")
+		return nil
+	}
+
+	name := opts.ExtractCoverTo
+	if filepath.Ext(name) == "" {
+		name += coverExtensions[img.mime]
+	}
+	coverPath := filepath.Join(opts.OutputDir, name)
+	if !opts.OverwriteFiles {
+		if _, err := os.Stat(coverPath); err == nil {
+			log.Printf("  Warning: Skipping cover, file already exists: %s", coverPath)
+			return nil
+		}
+	}
+
+	if err := writeFileAtomic(coverPath, func(ws io.WriteSeeker) error {
+		_, err := ws.Write(img.data)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to write cover: %w", err)
+	}
+	log.Printf("  Cover written: %s", coverPath)
+	return nil
+}
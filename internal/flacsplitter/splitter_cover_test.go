@@ -0,0 +1,116 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+)
+
+// pictureBlock returns a PICTURE metadata block holding data
+func pictureBlock(pictureType uint32, mime string, data []byte) *flac.MetaDataBlock {
+	var buf bytes.Buffer
+	write := func(v any) { binary.Write(&buf, binary.BigEndian, v) }
+	write(pictureType)
+	write(uint32(len(mime)))
+	buf.WriteString(mime)
+	write(uint32(0))   // description
+	write([4]uint32{}) // width, height, color depth and palette size
+	write(uint32(len(data)))
+	buf.Write(data)
+	return &flac.MetaDataBlock{Type: flac.Picture, Data: buf.Bytes()}
+}
+
+// brokenPicture is a PICTURE block cut off in its MIME type
+var brokenPicture = &flac.MetaDataBlock{Type: flac.Picture, Data: []byte{0, 0, 0, 3, 0, 0, 0, 200, 'i'}}
+
+func TestFrontCover(t *testing.T) {
+	link := pictureBlock(pictureFrontCover, pictureLinkMIME, []byte("https://example.com/cover.jpg"))
+	back := pictureBlock(4, "image/png", []byte("back"))
+	front := pictureBlock(pictureFrontCover, "image/jpeg", []byte("front"))
+
+	tests := []struct {
+		name     string
+		pictures []*flac.MetaDataBlock
+		want     string
+	}{
+		{"front cover", []*flac.MetaDataBlock{back, front}, "front"},
+		{"first picture", []*flac.MetaDataBlock{back}, "back"},
+		{"after a link and a broken block", []*flac.MetaDataBlock{link, brokenPicture, front}, "front"},
+		{"link only", []*flac.MetaDataBlock{link}, ""},
+		{"broken only", []*flac.MetaDataBlock{brokenPicture}, ""},
+		{"none", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, ok := frontCover(tt.pictures)
+			if ok != (tt.want != "") || string(img.data) != tt.want {
+				t.Errorf("frontCover() = %q, %t, want %q", img.data, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractCover(t *testing.T) {
+	flacPath := filepath.Join(t.TempDir(), "album.flac")
+	writeTestFlac(t, flacPath, testSignal(2, 4096))
+	pictures := []*flac.MetaDataBlock{
+		brokenPicture,
+		pictureBlock(pictureFrontCover, pictureLinkMIME, []byte("https://example.com/cover.jpg")),
+		pictureBlock(pictureFrontCover, "image/jpeg", []byte("jpeg data")),
+	}
+	if err := updateVorbisComment(flacPath, pictures, 0, func(*flacvorbis.MetaDataBlockVorbisComment) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := testOptions(t)
+	opts.ExtractCoverTo = "cover"
+	if err := extractCover(flacPath, opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(opts.OutputDir, "cover.jpg"))
+	if err != nil || string(data) != "jpeg data" {
+		t.Errorf("cover.jpg = %q, %v, want the embedded JPEG", data, err)
+	}
+
+	for _, name := range []string{"../cover.jpg", "art/cover.jpg", `art\cover.jpg`, ".."} {
+		opts.ExtractCoverTo = name
+		if err := extractCover(flacPath, opts); err == nil {
+			t.Errorf("extractCover() accepted the cover file name %q", name)
+		}
+	}
+}
+
+func TestSplitRejectsCoverPath(t *testing.T) {
+	cue, flacPath, _ := writeTestAlbum(t, 1,
+		"  TRACK 01 AUDIO", "    INDEX 01 00:00:00",
+	)
+	opts := testOptions(t)
+	opts.ExtractCoverTo = "../cover.jpg"
+
+	if err := Split(cue, flacPath, opts); err == nil {
+		t.Fatal("Split() accepted a cover file name with a directory")
+	}
+	if tracks := outputTracks(t, opts.OutputDir); len(tracks) != 0 {
+		t.Errorf("Split() wrote %v before rejecting the cover file name", tracks)
+	}
+}
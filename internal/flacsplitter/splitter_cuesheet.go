@@ -0,0 +1,125 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// writeTracksCue writes an "<album>.cue" sheet into the output directory that
+// describes the split tracks as they are, one FILE entry per track, so the
+// album can be played or burned from them as a whole. Tracks that were not
+// written, such as a separate hidden track 0, are left out.
+func writeTracksCue(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
+	// CD frames stand in for samples when the source cannot be probed
+	sampleRate, totalSamples := uint32(75), uint64(math.MaxUint64)
+	if detectAudioFormat(flacPath) == FormatFLAC {
+		if info, total, err := sourceLength(flacPath, opts); err == nil && info.SampleRate > 0 && total > 0 {
+			sampleRate, totalSamples = info.SampleRate, total
+		}
+	}
+
+	tracks := cue.Tracks
+	boundaries := CalculateBoundariesWithMode(cue, sampleRate, totalSamples, opts.BoundaryMode)
+	if opts.Mode == ModeGoAudioFull && len(boundaries) > 0 && boundaries[0].StartSample > 0 {
+		tracks, boundaries, _ = layoutHiddenTrack(cue, boundaries, opts)
+	}
+	offset := func(sample, fileStart uint64) string {
+		return formatCueTime(float64(sample-fileStart) / float64(sampleRate))
+	}
+
+	sheet := cue
+	sheet.Tracks = nil
+	var files []cueparser.TrackFile
+	var prev TrackBoundary
+	havePrev := false
+	for i, track := range tracks {
+		path := trackOutputPath(track, opts)
+		if track.Number == 0 || !opts.Tracks.Contains(track.Number) {
+			havePrev = false
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			havePrev = false
+			continue
+		}
+		rel, err := filepath.Rel(opts.OutputDir, path)
+		if err != nil {
+			rel = path
+		}
+
+		// A gap is inside the track's own file, at the end of the previous
+		// one when the track starts at INDEX 01, or in neither when dropped
+		start := boundaries[i].StartSample
+		file := cueparser.TrackFile{Name: filepath.ToSlash(rel)}
+		index := cueTimeToSample(track.Index, sampleRate)
+		if track.PreGap != "" {
+			switch gap := cueTimeToSample(track.PreGap, sampleRate); {
+			case gap >= start:
+				track.PreGap = offset(gap, start)
+			case havePrev && gap >= prev.StartSample && gap < prev.EndSample:
+				track.PreGap = offset(gap, prev.StartSample)
+				file.GapInPrevious = true
+			default:
+				track.PreGap = ""
+			}
+		}
+		track.Index = offset(max(index, start), start)
+		track.PregapSilence, track.PostgapSilence = "", ""
+
+		sheet.Tracks = append(sheet.Tracks, track)
+		files = append(files, file)
+		prev, havePrev = boundaries[i], true
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	album := chapterFilename(cue, flacPath, opts)
+	cuePath := filepath.Join(opts.OutputDir, strings.TrimSuffix(album, filepath.Ext(album))+".cue")
+	if same, err := sameFile(cuePath, cue.Path); err == nil && same {
+		return fmt.Errorf("the tracks CUE sheet would replace the source %s", cue.Path)
+	}
+
+	if err := writeFileAtomic(cuePath, func(ws io.WriteSeeker) error {
+		return cueparser.WriteTracks(ws, &sheet, files)
+	}); err != nil {
+		return fmt.Errorf("failed to write tracks CUE sheet: %w", err)
+	}
+
+	log.Printf("  Tracks CUE sheet written: %s (%d tracks)", cuePath, len(files))
+	return nil
+}
+
+// sameFile reports whether the paths a and b name the same existing file
+func sameFile(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(infoA, infoB), nil
+}
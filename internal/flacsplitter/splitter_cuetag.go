@@ -0,0 +1,66 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// cueSheetTag is the Vorbis comment some taggers store a whole CUE sheet in.
+// It is unrelated to the binary CUESHEET metadata block.
+const cueSheetTag = "CUESHEET"
+
+// ErrNoTaggedCue is returned by TaggedCue for FLAC files without a CUESHEET
+// Vorbis comment
+var ErrNoTaggedCue = errors.New("no CUESHEET tag")
+
+// TaggedCue parses the CUE sheet stored as text in the CUESHEET Vorbis
+// comment of flacPath. The sheet's FILE entry is replaced with flacPath
+// itself, since an embedded sheet usually still names the original rip.
+func TaggedCue(flacPath string, config *cueparser.ParserConfig) (cueparser.CueFile, error) {
+	if format := detectAudioFormat(flacPath); format != FormatFLAC {
+		return cueparser.CueFile{}, fmt.Errorf("%w: a CUESHEET tag can only be read from FLAC (detected %s)",
+			ErrUnsupportedInput, format)
+	}
+	cmts, err := readVorbisComment(flacPath)
+	if err != nil {
+		return cueparser.CueFile{}, err
+	}
+	sheets, err := cmts.Get(cueSheetTag)
+	if err != nil {
+		return cueparser.CueFile{}, fmt.Errorf("failed to read Vorbis comments: %v", err)
+	}
+	if len(sheets) == 0 {
+		return cueparser.CueFile{}, fmt.Errorf("%w in %s", ErrNoTaggedCue, flacPath)
+	}
+
+	name := filepath.Base(flacPath)
+	cue := cueparser.CueFile{
+		Path:         flacPath,
+		RelativePath: name,
+		FileName:     name,
+	}
+	if err := cueparser.ParseReader(&cue, strings.NewReader(sheets[0]), config); err != nil {
+		return cueparser.CueFile{}, err
+	}
+	cue.AudioFile = name
+	cue.AudioFileType = "WAVE"
+	return cue, nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"sync/atomic"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/meta"
+)
+
+// errNoSeekPoints reports a FLAC file whose seek table cannot split the
+// decoding work; the caller decodes it sequentially instead
+var errNoSeekPoints = errors.New("no usable seek points")
+
+// parallelReader is implemented by readers that can decode the whole stream
+// faster than block by block. readAllSamples prefers it and falls back to
+// ReadBlock when it returns errNoSeekPoints.
+type parallelReader interface {
+	readAll(onProgress func(decoded uint64)) ([][]int32, error)
+}
+
+// seekTableReader decodes a FLAC file in parallel: the seek table splits the
+// stream into ranges that start on frame boundaries, and each range is
+// decoded from its own file handle straight into the sample buffers
+type seekTableReader struct {
+	AudioReader
+	path    string
+	workers int
+}
+
+// readAll decodes the whole stream with up to r.workers goroutines
+func (r *seekTableReader) readAll(onProgress func(decoded uint64)) ([][]int32, error) {
+	info := r.Info()
+	starts, err := decodeStarts(r.path, info, r.workers)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([][]int32, info.NChannels)
+	for ch := range samples {
+		samples[ch] = make([]int32, info.NSamples)
+	}
+
+	rangeEnd := func(i int) uint64 {
+		if i+1 < len(starts) {
+			return starts[i+1]
+		}
+		return info.NSamples
+	}
+
+	ends := make([]uint64, len(starts))
+	errs := make([]error, len(starts))
+	var decoded atomic.Uint64
+	forEachLimit(len(starts), r.workers, func(i int) {
+		ends[i], errs[i] = decodeRange(r.path, samples, starts[i], rangeEnd(i), func(n int) {
+			total := decoded.Add(uint64(n))
+			if onProgress != nil {
+				onProgress(total)
+			}
+		})
+	})
+
+	for i := range starts {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if ends[i] < rangeEnd(i) {
+			// Only the last range may end early; splitAudio reports the
+			// truncation like it does for sequential decoding
+			if i+1 < len(starts) {
+				return nil, fmt.Errorf("failed to parse frame: stream ends at sample %d, before seek point %d", ends[i], starts[i+1])
+			}
+			for ch := range samples {
+				samples[ch] = samples[ch][:ends[i]]
+			}
+		}
+	}
+
+	return samples, nil
+}
+
+// decodeStarts picks up to workers seek points from the seek table of the
+// FLAC file at path that split its samples into ranges of roughly equal
+// length. The first range always starts at sample 0.
+func decodeStarts(path string, info *meta.StreamInfo, workers int) ([]uint64, error) {
+	if info.NSamples == 0 {
+		return nil, errNoSeekPoints
+	}
+
+	// ParseFile reads the metadata blocks only, not the audio frames
+	stream, err := flac.ParseFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seek table: %w", err)
+	}
+	defer stream.Close()
+
+	var points []uint64
+	for _, block := range stream.Blocks {
+		table, ok := block.Body.(*meta.SeekTable)
+		if !ok {
+			continue
+		}
+		for _, point := range table.Points {
+			if point.SampleNum != meta.PlaceholderPoint && point.SampleNum > 0 && point.SampleNum < info.NSamples {
+				points = append(points, point.SampleNum)
+			}
+		}
+	}
+	slices.Sort(points)
+
+	starts := []uint64{0}
+	for k := 1; k < workers; k++ {
+		// The last seek point at or before the ideal start of range k
+		target := info.NSamples * uint64(k) / uint64(workers)
+		i := sort.Search(len(points), func(i int) bool { return points[i] > target }) - 1
+		if i >= 0 && points[i] > starts[len(starts)-1] {
+			starts = append(starts, points[i])
+		}
+	}
+	if len(starts) < 2 {
+		return nil, errNoSeekPoints
+	}
+	return starts, nil
+}
+
+// decodeRange decodes the frames of the FLAC file at path from sample start
+// up to end into samples, calling onFrame with the length of each frame. It
+// returns the sample decoding stopped at, which is before end only when the
+// stream ends early.
+func decodeRange(path string, samples [][]int32, start, end uint64, onFrame func(n int)) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	stream, err := flac.NewSeek(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open FLAC file: %w", err)
+	}
+
+	pos := start
+	if start > 0 {
+		// With a seek table present Seek jumps straight to the seek point
+		// instead of scanning the whole stream
+		found, err := stream.Seek(start)
+		if err != nil {
+			return 0, fmt.Errorf("failed to seek to sample %d: %w", start, err)
+		}
+		if found != start {
+			return 0, fmt.Errorf("%w: seek point at sample %d is not on a frame boundary", errNoSeekPoints, start)
+		}
+	}
+
+	for pos < end {
+		f, err := stream.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return pos, fmt.Errorf("failed to parse frame at sample %d: %w", pos, err)
+		}
+		if len(f.Subframes) != len(samples) {
+			return pos, fmt.Errorf("decoded block has %d channels, want %d", len(f.Subframes), len(samples))
+		}
+
+		n := len(f.Subframes[0].Samples)
+		if pos+uint64(n) > uint64(len(samples[0])) {
+			return pos, fmt.Errorf("stream has more samples than its STREAMINFO declares (%d)", len(samples[0]))
+		}
+		for ch, subframe := range f.Subframes {
+			copy(samples[ch][pos:], subframe.Samples)
+		}
+		pos += uint64(n)
+		onFrame(n)
+	}
+
+	return pos, nil
+}
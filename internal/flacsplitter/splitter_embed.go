@@ -0,0 +1,202 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// CUESHEET block constants from the FLAC format specification
+const (
+	cdSamplesPerFrame = 588   // samples in one CD frame (1/75 s at 44.1 kHz)
+	cdLeadInSamples   = 88200 // the standard two second lead-in of a CD
+	cdLeadOutTrack    = 170
+	leadOutTrack      = 255
+	maxCueSheetTracks = 100 // tracks including the lead-out
+)
+
+// EmbedCue writes a copy of flacPath into opts.OutputDir with a CUESHEET
+// metadata block built from cue, replacing any existing one, so the album
+// stays a single self-describing file. With opts.EmbedTags the Vorbis
+// comments are replaced with the album tags as well.
+func EmbedCue(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
+	if err := prepareCue(&cue, opts); err != nil {
+		return err
+	}
+	if format := detectAudioFormat(flacPath); format != FormatFLAC {
+		return fmt.Errorf("%w: a CUESHEET can only be embedded in FLAC (detected %s)", ErrUnsupportedInput, format)
+	}
+	fillFromID3(&cue, flacPath)
+
+	outputFile := filepath.Join(opts.OutputDir, chapterFilename(cue, flacPath, opts))
+	if !opts.OverwriteFiles {
+		if _, err := os.Stat(outputFile); err == nil {
+			log.Printf("  Warning: Skipping album, output file already exists: %s", outputFile)
+			return nil
+		}
+	}
+
+	input, err := os.Open(flacPath)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	// go-flac expects the fLaC marker first, so drop any ID3v2 prefix
+	if _, err := skipID3v2(input); err != nil {
+		return err
+	}
+	f, err := flac.ParseBytes(input)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC file: %v", err)
+	}
+	info, err := f.GetStreamInfo()
+	if err != nil {
+		return fmt.Errorf("failed to read STREAMINFO: %v", err)
+	}
+
+	block, err := cueSheetBlock(cue, uint32(info.SampleRate), uint64(info.SampleCount), info.BitDepth == 16 && info.ChannelCount == 2)
+	if err != nil {
+		return err
+	}
+
+	// STREAMINFO must stay the first block
+	kept := []*flac.MetaDataBlock{f.Meta[0], block}
+	for _, meta := range f.Meta[1:] {
+		if meta.Type != flac.CueSheet {
+			kept = append(kept, meta)
+		}
+	}
+	f.Meta = kept
+
+	if opts.EmbedTags {
+		err := retag(f, nil, func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+			addMappedTags(cmts, albumFileValues(cue, uint8(info.ChannelCount), opts), cue, nil, opts)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	setPadding(f, opts.PaddingBytes)
+
+	err = writeFileAtomic(outputFile, func(ws io.WriteSeeker) error {
+		_, err := ws.Write(f.Marshal())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("  CUESHEET with %d tracks embedded: %s", len(cue.Tracks), outputFile)
+	return nil
+}
+
+// cueSheetBlock encodes the tracks of cue as a CUESHEET metadata block for
+// audio of totalSamples samples. Tracks start at their INDEX 00 when they
+// have one, with index points relative to that start. The sheet is flagged
+// as CD-DA when the audio is 44.1 kHz CD audio and every offset falls on a
+// CD frame.
+func cueSheetBlock(cue cueparser.CueFile, sampleRate uint32, totalSamples uint64, cdFormat bool) (*flac.MetaDataBlock, error) {
+	if len(cue.Tracks)+1 > maxCueSheetTracks {
+		return nil, fmt.Errorf("a CUESHEET holds at most %d tracks, got %d", maxCueSheetTracks-1, len(cue.Tracks))
+	}
+	if totalSamples == 0 {
+		return nil, fmt.Errorf("the FLAC file does not record its length")
+	}
+
+	type indexPoint struct {
+		offset uint64
+		number uint8
+	}
+	type sheetTrack struct {
+		offset  uint64
+		number  uint8
+		isrc    string
+		indices []indexPoint
+	}
+
+	isCD := cdFormat && sampleRate == 44100 && totalSamples%cdSamplesPerFrame == 0
+	tracks := make([]sheetTrack, 0, len(cue.Tracks))
+	for _, track := range cue.Tracks {
+		if track.Number < 1 || track.Number > 99 {
+			return nil, fmt.Errorf("track %d: CUESHEET track numbers must be 1-99", track.Number)
+		}
+		start := cueTimeToSample(trackStart(track, BoundaryIndex00), sampleRate)
+		index := cueTimeToSample(track.Index, sampleRate)
+		if start >= totalSamples {
+			return nil, fmt.Errorf("track %d starts after the end of the audio", track.Number)
+		}
+
+		t := sheetTrack{offset: start, number: uint8(track.Number), isrc: track.ISRC}
+		if index > start {
+			t.indices = append(t.indices, indexPoint{0, 0})
+		}
+		t.indices = append(t.indices, indexPoint{index - start, 1})
+		tracks = append(tracks, t)
+
+		if start%cdSamplesPerFrame != 0 || index%cdSamplesPerFrame != 0 {
+			isCD = false
+		}
+	}
+
+	var buf bytes.Buffer
+	write := func(v any) { binary.Write(&buf, binary.BigEndian, v) }
+	fixed := func(s string, n int) {
+		b := make([]byte, n)
+		copy(b, s)
+		buf.Write(b)
+	}
+
+	fixed(cue.Catalog, 128)
+	leadIn, flags, leadOut := uint64(0), byte(0), uint8(leadOutTrack)
+	if isCD {
+		leadIn, flags, leadOut = cdLeadInSamples, 0x80, cdLeadOutTrack
+	}
+	write(leadIn)
+	write(flags)
+	buf.Write(make([]byte, 258))
+	write(uint8(len(tracks) + 1))
+
+	for _, t := range tracks {
+		write(t.offset)
+		write(t.number)
+		fixed(t.isrc, 12)
+		buf.Write(make([]byte, 14)) // audio track, no pre-emphasis, reserved
+		write(uint8(len(t.indices)))
+		for _, idx := range t.indices {
+			write(idx.offset)
+			write(idx.number)
+			buf.Write(make([]byte, 3))
+		}
+	}
+
+	// The lead-out track marks the end of the audio and has no index points
+	write(totalSamples)
+	write(leadOut)
+	buf.Write(make([]byte, 12+14))
+	write(uint8(0))
+
+	return &flac.MetaDataBlock{Type: flac.CueSheet, Data: buf.Bytes()}, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// ErrExternalTimeout is returned when an external tool exceeds ExternalTimeout
+var ErrExternalTimeout = errors.New("external tool timed out")
+
+// runExternal runs an external tool and returns its combined output. When
+// opts.ExternalTimeout is set the whole process group is killed on expiry.
+func runExternal(opts *SplitOptions, name string, args ...string) ([]byte, error) {
+	ctx := context.Background()
+	if opts.ExternalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.ExternalTimeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if opts.ExternalTimeout > 0 {
+		// Without a timeout the tool stays in our process group, where the
+		// terminal's Ctrl-C reaches it directly
+		setProcessGroup(cmd)
+	}
+	// Don't wait forever on pipes held open by orphaned children
+	cmd.WaitDelay = 5 * time.Second
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	untrack := trackProcessGroup(cmd)
+	err := cmd.Wait()
+	untrack()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output.Bytes(), fmt.Errorf("%s killed after %s: %w", name, opts.ExternalTimeout, ErrExternalTimeout)
+	}
+	return output.Bytes(), err
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package flacsplitter
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group and makes context
+// cancellation kill the whole group so child processes don't linger. The
+// group no longer receives the terminal's Ctrl-C, so trackProcessGroup
+// forwards SIGINT and SIGTERM to it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// processGroups holds the process groups of the running external tools
+var processGroups = struct {
+	sync.Mutex
+	pids    map[int]bool
+	signals chan os.Signal
+}{pids: make(map[int]bool)}
+
+// trackProcessGroup forwards SIGINT and SIGTERM to the process group of the
+// started cmd, if setProcessGroup gave it one, until untrack is called. The
+// signal then terminates flac-splitter as it would have without the handler.
+func trackProcessGroup(cmd *exec.Cmd) (untrack func()) {
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		return func() {}
+	}
+	pid := cmd.Process.Pid
+
+	processGroups.Lock()
+	defer processGroups.Unlock()
+	if len(processGroups.pids) == 0 {
+		processGroups.signals = make(chan os.Signal, 1)
+		signal.Notify(processGroups.signals, syscall.SIGINT, syscall.SIGTERM)
+		go forwardSignals(processGroups.signals)
+	}
+	processGroups.pids[pid] = true
+
+	return func() {
+		processGroups.Lock()
+		defer processGroups.Unlock()
+		delete(processGroups.pids, pid)
+		if len(processGroups.pids) == 0 {
+			signal.Stop(processGroups.signals)
+			close(processGroups.signals)
+		}
+	}
+}
+
+// forwardSignals sends the first signal received on signals to every tracked
+// process group, then re-raises it with the default action
+func forwardSignals(signals chan os.Signal) {
+	sig, ok := <-signals
+	if !ok {
+		return
+	}
+
+	processGroups.Lock()
+	for pid := range processGroups.pids {
+		syscall.Kill(-pid, sig.(syscall.Signal))
+	}
+	processGroups.Unlock()
+
+	signal.Reset(sig)
+	syscall.Kill(os.Getpid(), sig.(syscall.Signal))
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package flacsplitter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRunExternalTimeoutKillsProcessGroup(t *testing.T) {
+	opts := DefaultOptions(t.TempDir())
+	opts.ExternalTimeout = 100 * time.Millisecond
+
+	// The background sleep holds the output pipe open; only a group kill
+	// ends it before WaitDelay
+	start := time.Now()
+	_, err := runExternal(opts, "sh", "-c", "sleep 30 & sleep 30")
+	if !errors.Is(err, ErrExternalTimeout) {
+		t.Fatalf("runExternal() error = %v, want ErrExternalTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("runExternal() took %s, want the whole group killed at the timeout", elapsed)
+	}
+}
+
+func TestRunExternalWithoutTimeoutKeepsProcessGroup(t *testing.T) {
+	opts := DefaultOptions(t.TempDir())
+
+	output, err := runExternal(opts, "sh", "-c", "ps -o pgid= -p $$; ps -o pgid= -p $PPID")
+	if err != nil {
+		t.Skipf("ps not usable: %v", err)
+	}
+	var child, parent int
+	if _, err := fmt.Sscan(string(output), &child, &parent); err != nil {
+		t.Skipf("unexpected ps output %q", output)
+	}
+	if child != parent {
+		t.Errorf("tool ran in process group %d, want ours (%d)", child, parent)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package flacsplitter
+
+import "os/exec"
+
+// setProcessGroup is a no-op on Windows, which has no process groups to
+// kill: on timeout context cancellation kills only the tool itself, and any
+// processes it started (such as the flac decoder shnsplit runs) keep running
+// until they finish. A job object would be needed to kill them too.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// trackProcessGroup is a no-op on Windows, where Ctrl-C reaches every
+// process attached to the console
+func trackProcessGroup(cmd *exec.Cmd) (untrack func()) {
+	return func() {}
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// Boundary is one track of SplitByBoundaries, given in samples of the source
+type Boundary struct {
+	StartSample uint64 // First sample of the track
+	EndSample   uint64 // One past the last sample of the track (0 = end of the audio)
+
+	// Title is written as TITLE and used for the file name
+	Title string
+
+	// Tags are extra Vorbis comments for the track, by comment name
+	Tags map[string]string
+}
+
+// SplitByBoundaries splits the FLAC file at flacPath into one track per
+// boundary, computed elsewhere (silence detection, a beat grid, ...) rather
+// than read from a CUE sheet. It runs the pure Go decode and encode pipeline
+// CUE sheets go through in SplitWithGoAudio: tracks are numbered in order,
+// named with FilenamePattern (renamed or rejected like duplicate CUE titles),
+// tagged with their title, number and Tags plus the source's pictures, and
+// Tracks, BlockSize, Normalize, Gapless and the other pure Go options apply.
+// Gapless needs boundaries that follow each other from sample 0 to the end
+// of the audio. Options that only make sense for a CUE sheet are ignored.
+func SplitByBoundaries(flacPath string, boundaries []Boundary, opts *SplitOptions) error {
+	if len(boundaries) == 0 {
+		return fmt.Errorf("no boundaries to split at")
+	}
+	for i, b := range boundaries {
+		if b.EndSample != 0 && b.StartSample >= b.EndSample {
+			return fmt.Errorf("boundary %d: invalid sample range %d-%d", i+1, b.StartSample, b.EndSample)
+		}
+	}
+	if err := checkGaplessOptions(opts); err != nil {
+		return err
+	}
+	if opts.Gapless {
+		if err := checkContiguous(boundaries); err != nil {
+			return err
+		}
+	}
+	if opts.OutputFormat != "" && opts.OutputFormat != OutputFLAC && opts.OutputFormat != OutputWAV {
+		return fmt.Errorf("unknown output format %q", opts.OutputFormat)
+	}
+	if err := validatePadding(opts); err != nil {
+		return err
+	}
+	if err := validateNumberFormats(opts); err != nil {
+		return err
+	}
+
+	// The tracks stand in for a CUE sheet for file names and the track hook
+	name := filepath.Base(flacPath)
+	cue := cueparser.CueFile{Path: flacPath, FileName: name, AudioFile: name}
+	for i, b := range boundaries {
+		cue.Tracks = append(cue.Tracks, cueparser.Track{Number: i + 1, Title: b.Title})
+	}
+
+	album := *opts
+	album.Mode = ModeGoAudioFull
+	opts = &album
+	if err := resolveOutputNames(cue, opts); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	openReader := opts.OpenReader
+	if openReader == nil {
+		openReader = OpenFlacReader
+	}
+	stream, err := openReader(flacPath)
+	if err != nil {
+		return fmt.Errorf("failed to open FLAC file: %v", err)
+	}
+	defer stream.Close()
+
+	log.Printf("  Splitting %s at %d explicit boundaries", name, len(boundaries))
+	return splitDecoded(stream, func(audio *decodedAudio) ([]*encodeJob, error) {
+		return boundaryJobs(cue, boundaries, audio, opts)
+	}, fileTracks(cue, opts, sourcePictures(flacPath, opts)), opts)
+}
+
+// checkContiguous checks that every boundary starts where the previous one
+// ends, the first at sample 0, as gapless verification needs
+func checkContiguous(boundaries []Boundary) error {
+	var next uint64
+	for i, b := range boundaries {
+		if b.StartSample != next {
+			return fmt.Errorf("gapless verification needs contiguous boundaries: boundary %d starts at sample %d, not %d",
+				i+1, b.StartSample, next)
+		}
+		if b.EndSample == 0 && i < len(boundaries)-1 {
+			return fmt.Errorf("gapless verification needs contiguous boundaries: only the last one can end with the audio, not boundary %d", i+1)
+		}
+		next = b.EndSample
+	}
+	return nil
+}
+
+// boundaryJobs cuts the decoded audio at boundaries, the tracks of cue
+func boundaryJobs(cue cueparser.CueFile, boundaries []Boundary, audio *decodedAudio, opts *SplitOptions) ([]*encodeJob, error) {
+	totalSamples := audio.total()
+	total := strconv.Itoa(len(boundaries))
+	var jobs []*encodeJob
+	for i, b := range boundaries {
+		trackRange := sampleRange{start: b.StartSample, end: b.EndSample}
+		if b.EndSample == 0 {
+			trackRange.end = totalSamples
+		}
+		if trackRange.start >= trackRange.end || trackRange.end > totalSamples {
+			return nil, fmt.Errorf("boundary %d: sample range %d-%d exceeds the %d samples of the audio",
+				i+1, trackRange.start, trackRange.end, totalSamples)
+		}
+
+		track := cue.Tracks[i]
+		if !opts.Tracks.Contains(track.Number) {
+			continue
+		}
+		values := []tagValue{
+			{FieldTitle, b.Title},
+			{FieldTrackNumber, formatNumber(strconv.Itoa(track.Number), total, opts.TrackNumberFormat)},
+			{FieldTotalTracks, total},
+			{FieldChannelMask, channelMaskTag(audio.info.NChannels)},
+		}
+		jobs = append(jobs, &encodeJob{
+			track:      track,
+			trackRange: trackRange,
+			tag:        boundaryTags(values, b.Tags, cue, opts),
+		})
+	}
+	return jobs, nil
+}
+
+// boundaryTags returns the fill function adding a boundary's mapped values
+// followed by its own tags in name order
+func boundaryTags(values []tagValue, tags map[string]string, cue cueparser.CueFile,
+	opts *SplitOptions) func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+		addMappedTags(cmts, values, cue, nil, opts)
+		for _, name := range names {
+			cmts.Add(strings.ToUpper(name), tags[name])
+		}
+	}
+}
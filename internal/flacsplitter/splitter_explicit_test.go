@@ -0,0 +1,138 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSplitByBoundaries(t *testing.T) {
+	flacPath := filepath.Join(t.TempDir(), "set.flac")
+	source := testSignal(2, 3*testSampleRate)
+	writeTestFlac(t, flacPath, source)
+	boundaries := []Boundary{
+		{StartSample: 0, EndSample: 50000, Title: "Opening"},
+		{StartSample: 50000, EndSample: 100000, Title: "Middle", Tags: map[string]string{"bpm": "128"}},
+		{StartSample: 100000, Title: "Closing"},
+	}
+
+	opts := testOptions(t)
+	opts.Gapless = true
+	if err := SplitByBoundaries(flacPath, boundaries, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks := outputTracks(t, opts.OutputDir)
+	want := []string{"01 - Opening.flac", "02 - Middle.flac", "03 - Closing.flac"}
+	if len(tracks) != len(want) {
+		t.Fatalf("got tracks %v, want %v", tracks, want)
+	}
+	ranges := []sampleRange{{0, 50000}, {50000, 100000}, {100000, 3 * testSampleRate}}
+	for i, track := range tracks {
+		if filepath.Base(track) != want[i] {
+			t.Errorf("track %d is %s, want %s", i+1, filepath.Base(track), want[i])
+		}
+		samples, _ := readTestFlac(t, track)
+		for ch := range samples {
+			if !slices.Equal(samples[ch], source[ch][ranges[i].start:ranges[i].end]) {
+				t.Errorf("track %d does not hold source samples %d-%d", i+1, ranges[i].start, ranges[i].end)
+				break
+			}
+		}
+	}
+
+	cmts, err := readVorbisComment(tracks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{"TITLE": "Middle", "TRACKNUMBER": "2", "TRACKTOTAL": "3", "BPM": "128"} {
+		if got, _ := cmts.Get(name); !slices.Equal(got, []string{value}) {
+			t.Errorf("track 2 %s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestSplitByBoundariesDuplicateTitles(t *testing.T) {
+	flacPath := filepath.Join(t.TempDir(), "set.flac")
+	writeTestFlac(t, flacPath, testSignal(2, testSampleRate))
+	boundaries := []Boundary{
+		{StartSample: 0, EndSample: 20000, Title: "Untitled"},
+		{StartSample: 20000, Title: "Untitled"},
+	}
+
+	opts := testOptions(t)
+	opts.FilenamePattern = "%[2]s.flac"
+	if err := SplitByBoundaries(flacPath, boundaries, opts); !errors.Is(err, ErrDuplicateOutput) {
+		t.Fatalf("SplitByBoundaries() error = %v, want ErrDuplicateOutput", err)
+	}
+	if tracks := outputTracks(t, opts.OutputDir); len(tracks) != 0 {
+		t.Errorf("SplitByBoundaries() wrote %v before rejecting the duplicate", tracks)
+	}
+
+	opts.RenameDuplicates = true
+	if err := SplitByBoundaries(flacPath, boundaries, opts); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, track := range outputTracks(t, opts.OutputDir) {
+		names = append(names, filepath.Base(track))
+	}
+	if want := []string{"Untitled (2).flac", "Untitled.flac"}; !slices.Equal(names, want) {
+		t.Errorf("got tracks %v, want %v", names, want)
+	}
+}
+
+func TestSplitByBoundariesRejects(t *testing.T) {
+	flacPath := filepath.Join(t.TempDir(), "set.flac")
+	writeTestFlac(t, flacPath, testSignal(2, testSampleRate))
+	halves := []Boundary{{StartSample: 0, EndSample: 20000}, {StartSample: 20000}}
+
+	tests := []struct {
+		name       string
+		boundaries []Boundary
+		set        func(opts *SplitOptions)
+	}{
+		{"no boundaries", nil, func(*SplitOptions) {}},
+		{"empty range", []Boundary{{StartSample: 500, EndSample: 500}}, func(*SplitOptions) {}},
+		{"past the end", []Boundary{{StartSample: 0, EndSample: 2 * testSampleRate}}, func(*SplitOptions) {}},
+		{"gapless with normalization", halves, func(opts *SplitOptions) { opts.Normalize = NormalizePeak }},
+		{"gapless with a track selection", halves, func(opts *SplitOptions) { opts.Tracks = TrackSelection{{1, 1}} }},
+		{"gapless with pregap silence", halves, func(opts *SplitOptions) { opts.PregapMode = PregapInsertSilence }},
+		{"gapless without INDEX 00 gaps", halves, func(opts *SplitOptions) { opts.BoundaryMode = BoundaryDropGaps }},
+		{"gapless with a gap", []Boundary{{StartSample: 0, EndSample: 20000}, {StartSample: 21000}}, func(*SplitOptions) {}},
+		{"gapless with an overlap", []Boundary{{StartSample: 0, EndSample: 20000}, {StartSample: 19000}}, func(*SplitOptions) {}},
+		{"gapless after sample 0", []Boundary{{StartSample: 100}}, func(*SplitOptions) {}},
+		{"gapless with an open middle", []Boundary{{StartSample: 0}, {StartSample: 20000}}, func(*SplitOptions) {}},
+		{"gapless short of the end", []Boundary{{StartSample: 0, EndSample: 20000}, {StartSample: 20000, EndSample: 40000}},
+			func(*SplitOptions) {}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions(t)
+			opts.Gapless = true
+			tt.set(opts)
+			if err := SplitByBoundaries(flacPath, tt.boundaries, opts); err == nil {
+				t.Fatal("SplitByBoundaries() succeeded")
+			}
+			if tracks := outputTracks(t, opts.OutputDir); len(tracks) != 0 {
+				t.Errorf("SplitByBoundaries() wrote %v before failing", tracks)
+			}
+		})
+	}
+}
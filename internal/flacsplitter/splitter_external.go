@@ -16,252 +16,241 @@ package flacsplitter
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"strconv"
+	"strings"
 
-	"github.com/go-flac/flacvorbis"
-	"github.com/go-flac/go-flac"
 	"github.com/ldmonster/flac-splitter/internal/cueparser"
 )
 
-// splitWithExternalTools uses shnsplit or ffmpeg for splitting
+// splitWithExternalTools splits with the external splitter chosen by selectSplitter
 func splitWithExternalTools(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
-	// Check which tool is available
-	hasShnsplit := executableExists("shnsplit")
-	hasFFmpeg := executableExists("ffmpeg")
-
-	if !hasShnsplit && !hasFFmpeg {
-		return fmt.Errorf("neither shnsplit nor ffmpeg found - please install one of them")
+	splitter, err := selectSplitter(cue, flacPath, opts)
+	if err != nil {
+		return err
 	}
 
-	// Choose splitter
-	if opts.UseFFmpeg && hasFFmpeg {
-		return splitWithFFmpeg(cue, flacPath, opts)
-	} else if hasShnsplit {
-		return splitWithShnsplit(cue, flacPath, opts)
-	} else if hasFFmpeg {
-		return splitWithFFmpeg(cue, flacPath, opts)
+	// Validate the CUE layout against the STREAMINFO duration where available
+	if detectAudioFormat(flacPath) == FormatFLAC {
+		duration, err := probeFlacDuration(flacPath, opts)
+		if err != nil {
+			return err
+		}
+		if err := checkDuration(cue, duration); err != nil {
+			return err
+		}
 	}
 
-	return fmt.Errorf("no suitable audio splitter found")
+	return splitter.run(cue, flacPath, opts)
 }
 
+// lookPath resolves external commands; replaceable to simulate installed tools
+var lookPath = exec.LookPath
+
 // executableExists checks if a command is available in PATH
 func executableExists(name string) bool {
-	_, err := exec.LookPath(name)
+	_, err := lookPath(name)
 	return err == nil
 }
 
 // splitWithShnsplit uses shnsplit to split the FLAC file
 func splitWithShnsplit(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
-	// Create temporary CUE file with absolute path
-	tempCuePath := filepath.Join(opts.OutputDir, "temp.cue")
-	if err := copyCueFile(cue.Path, tempCuePath, flacPath); err != nil {
+	// Create a uniquely named temporary CUE file with absolute path, so albums
+	// sharing an output directory never overwrite each other's copy
+	if len(opts.outputNames) > 0 {
+		return fmt.Errorf("%w: shnsplit cannot rename tracks with duplicate file names", ErrDuplicateOutput)
+	}
+	if opts.BoundaryMode == BoundaryDropGaps {
+		log.Printf("  Warning: shnsplit cannot drop INDEX 00 gaps, they stay at the end of the previous track")
+	}
+	tempCuePath, err := copyCueFile(cue.Path, opts.OutputDir, flacPath, opts.BoundaryMode)
+	if err != nil {
 		return fmt.Errorf("failed to create temporary CUE file: %v", err)
 	}
-	defer os.Remove(tempCuePath)
+	if opts.KeepTempFiles {
+		log.Printf("  Keeping temporary CUE file: %s", tempCuePath)
+	} else {
+		defer os.Remove(tempCuePath)
+	}
 
-	// Run shnsplit
-	cmd := exec.Command("shnsplit",
+	// Run shnsplit; -O keeps it from prompting when a track already exists
+	overwrite := "never"
+	if opts.OverwriteFiles {
+		overwrite = "always"
+	}
+	output, attempts, err := runExternalRetry(opts, "shnsplit",
+		"-O", overwrite,
 		"-f", tempCuePath,
-		"-t", "%n - %t",
+		"-t", shnsplitTemplate(opts.FilenamePattern),
+		"-n", shnsplitNumberFormat(opts.FilenamePattern),
 		"-o", "flac",
 		"-d", opts.OutputDir,
 		flacPath,
 	)
-
-	output, err := cmd.CombinedOutput()
+	if errors.Is(err, ErrExternalTimeout) {
+		return err
+	}
 	if err != nil {
-		return fmt.Errorf("shnsplit failed: %v\nOutput: %s", err, string(output))
+		return fmt.Errorf("shnsplit failed after %d attempt(s): %v\nOutput: %s", attempts, err, string(output))
 	}
 
 	log.Printf("  Split complete with shnsplit")
 
-	// Apply metadata tags using go-flac
-	return applyMetadataTags(cue, opts)
-}
-
-// splitWithFFmpeg uses ffmpeg to split the FLAC file
-func splitWithFFmpeg(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
-	for i, track := range cue.Tracks {
-		// Calculate start time
-		startTime := convertCueTimeToSeconds(track.Index)
-
-		// Calculate duration
-		var duration string
-		if i < len(cue.Tracks)-1 {
-			duration = calculateDuration(track.Index, cue.Tracks[i+1].Index)
-		}
-
-		// Output filename
-		outputFile := filepath.Join(opts.OutputDir,
-			fmt.Sprintf(opts.FilenamePattern, track.Number, sanitizeFilename(track.Title)))
-
-		// Build ffmpeg command - use copy codec for speed
-		args := []string{
-			"-i", flacPath,
-			"-ss", startTime,
-		}
-
-		if duration != "" {
-			args = append(args, "-t", duration)
+	// shnsplit always writes every track; drop the ones outside the selection
+	for _, track := range cue.Tracks {
+		if !opts.Tracks.Contains(track.Number) {
+			os.Remove(trackOutputPath(track, opts))
 		}
+	}
 
-		args = append(args, "-acodec", "copy")
+	// Apply metadata tags using go-flac
+	return applyMetadataTags(cue, flacPath, opts)
+}
 
-		if opts.OverwriteFiles {
-			args = append(args, "-y")
-		}
+// trackNumberVerb matches the zero-padded track number of a FilenamePattern
+var trackNumberVerb = regexp.MustCompile(`%0\d+d`)
 
-		args = append(args, outputFile)
+// shnsplitTemplate converts a FilenamePattern into a shnsplit -t format,
+// e.g. "%02d - %s.flac" into "%n - %t"
+func shnsplitTemplate(pattern string) string {
+	template := strings.TrimSuffix(pattern, filepath.Ext(pattern))
+	template = trackNumberVerb.ReplaceAllLiteralString(template, "%n")
+	return strings.ReplaceAll(template, "%s", "%t")
+}
 
-		cmd := exec.Command("ffmpeg", args...)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			log.Printf("  Warning: Failed to extract track %d: %v", track.Number, err)
-			log.Printf("  FFmpeg output: %s", string(output))
-			continue
-		}
+// shnsplitNumberFormat returns the shnsplit -n format that pads %n like the
+// FilenamePattern pads the track number
+func shnsplitNumberFormat(pattern string) string {
+	if verb := trackNumberVerb.FindString(pattern); verb != "" {
+		return verb
 	}
-
-	log.Printf("  Split complete with ffmpeg")
-
-	// Apply metadata tags using go-flac
-	return applyMetadataTags(cue, opts)
+	return "%02d"
 }
 
-// applyMetadataTags applies metadata to all split tracks
-func applyMetadataTags(cue cueparser.CueFile, opts *SplitOptions) error {
+// applyMetadataTags applies metadata and the pictures of audioPath to all split tracks
+func applyMetadataTags(cue cueparser.CueFile, audioPath string, opts *SplitOptions) error {
 	log.Printf("  Writing metadata tags with go-flac...")
 	tagErrors := 0
+	tagged := 0
+	pictures := sourcePictures(audioPath, opts)
 
 	for _, track := range cue.Tracks {
-		trackFile := filepath.Join(opts.OutputDir,
-			fmt.Sprintf(opts.FilenamePattern, track.Number, sanitizeFilename(track.Title)))
-
-		if err := writeFlacTags(trackFile, cue, track, track.Number); err != nil {
-			log.Printf("  Warning: Failed to write tags for track %d: %v", track.Number, err)
-			tagErrors++
+		if !opts.Tracks.Contains(track.Number) {
+			continue
 		}
-	}
+		tagged++
+		trackFile := trackOutputPath(track, opts)
 
-	if tagErrors == 0 {
-		log.Printf("  Metadata tags written successfully for all %d tracks", len(cue.Tracks))
-	} else {
-		log.Printf("  Metadata tags written (%d/%d tracks had errors)", tagErrors, len(cue.Tracks))
-	}
-
-	return nil
-}
-
-// writeFlacTags writes metadata tags to a FLAC file
-func writeFlacTags(flacPath string, cue cueparser.CueFile, track cueparser.Track, trackNum int) error {
-	// Open the FLAC file
-	f, err := flac.ParseFile(flacPath)
-	if err != nil {
-		return fmt.Errorf("failed to parse FLAC file: %v", err)
-	}
-
-	// Get or create VorbisComment metadata block
-	var cmtsmeta *flac.MetaDataBlock
-	for _, meta := range f.Meta {
-		if meta.Type == flac.VorbisComment {
-			cmtsmeta = meta
-			break
+		// The channel layout is taken from the split file itself, whatever the source
+		src := trackSource{pictures: pictures}
+		if info, err := probeStreamInfo(trackFile); err == nil {
+			src.channels = info.NChannels
 		}
-	}
 
-	var cmts *flacvorbis.MetaDataBlockVorbisComment
-	if cmtsmeta != nil {
-		cmts, err = flacvorbis.ParseFromMetaDataBlock(*cmtsmeta)
-		if err != nil {
-			return fmt.Errorf("failed to parse vorbis comment: %v", err)
+		if err := writeFlacTags(trackFile, cue, track, track.Number, src, opts); err != nil {
+			log.Printf("  Warning: Failed to write tags for track %d: %v", track.Number, err)
+			tagErrors++
+			continue
+		}
+		if err := runTrackHook(cue, track, trackFile, opts); err != nil {
+			return err
 		}
-	} else {
-		cmts = flacvorbis.New()
-	}
-
-	// Clear existing comments to avoid duplicates
-	cmts.Comments = nil
-
-	// Add standard tags
-	cmts.Add(flacvorbis.FIELD_TITLE, track.Title)
-	cmts.Add(flacvorbis.FIELD_ARTIST, track.Performer)
-	cmts.Add(flacvorbis.FIELD_ALBUM, cue.Album)
-	cmts.Add(flacvorbis.FIELD_PERFORMER, cue.Performer)
-	cmts.Add(flacvorbis.FIELD_TRACKNUMBER, strconv.Itoa(trackNum))
-	cmts.Add("TOTALTRACKS", strconv.Itoa(len(cue.Tracks)))
-
-	// Add optional tags
-	if cue.Date != "" {
-		cmts.Add(flacvorbis.FIELD_DATE, cue.Date)
-	}
-	if cue.Genre != "" {
-		cmts.Add(flacvorbis.FIELD_GENRE, cue.Genre)
-	}
-	if cue.Comment != "" {
-		cmts.Add(flacvorbis.FIELD_DESCRIPTION, cue.Comment)
-	}
-	if cue.Catalog != "" {
-		cmts.Add("CATALOG", cue.Catalog)
-	}
-	if cue.DiscID != "" {
-		cmts.Add("DISCID", cue.DiscID)
 	}
 
-	// Marshal to metadata block
-	res := cmts.Marshal()
-
-	// Update or add the VorbisComment block
-	if cmtsmeta != nil {
-		*cmtsmeta = res
+	if tagErrors == 0 {
+		log.Printf("  Metadata tags written successfully for all %d tracks", tagged)
 	} else {
-		f.Meta = append(f.Meta, &res)
-	}
-
-	// Save the file
-	if err := f.Save(flacPath); err != nil {
-		return fmt.Errorf("failed to save FLAC file: %v", err)
+		log.Printf("  Metadata tags written (%d/%d tracks had errors)", tagErrors, tagged)
 	}
 
 	return nil
 }
 
-// copyCueFile copies a CUE file and adjusts the FILE path to be absolute
-func copyCueFile(srcPath, dstPath, flacPath string) error {
+// copyCueFile copies a CUE file into a new temporary file in dir, adjusting
+// the FILE path to be absolute, and returns the path of the copy
+func copyCueFile(srcPath, dir, flacPath string, mode BoundaryMode) (string, error) {
 	input, err := os.Open(srcPath)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer input.Close()
 
-	output, err := os.Create(dstPath)
+	output, err := os.CreateTemp(dir, "temp-*.cue")
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer output.Close()
 
-	scanner := bufio.NewScanner(input)
-	writer := bufio.NewWriter(output)
-	defer writer.Flush()
+	if err := rewriteCue(input, output, flacPath, mode); err != nil {
+		output.Close()
+		os.Remove(output.Name())
+		return "", err
+	}
+	if err := output.Close(); err != nil {
+		os.Remove(output.Name())
+		return "", err
+	}
 
-	filePattern := regexp.MustCompile(`FILE\s+"([^"]+)"\s+WAVE`)
+	return output.Name(), nil
+}
 
+// rewriteCue copies CUE sheet lines from r to w with FILE pointing at flacPath.
+// shnsplit always splits at INDEX 01, so with BoundaryIndex00 each INDEX 01
+// is moved to the track's INDEX 00 and the INDEX 00 line is dropped. It only
+// reads MM:SS:FF, so HH:MM:SS:FF indexes are rewritten with the hours folded
+// into the minutes.
+func rewriteCue(r io.Reader, w io.Writer, flacPath string, mode BoundaryMode) error {
+	// A BOM would leak into the first directive, and shnsplit cannot read UTF-16
+	scanner := bufio.NewScanner(cueparser.DecodeBOM(r))
+	scanner.Split(cueparser.ScanLines)
+	writer := bufio.NewWriter(w)
+
+	filePattern := regexp.MustCompile(`FILE\s+"([^"]+)"\s+(\w+)`)
+	indexPattern := regexp.MustCompile(`^(\s*INDEX\s+)(\d+)(\s+)(\S+)`)
+	trackPattern := regexp.MustCompile(`^\s*TRACK\s`)
+
+	var pregap string
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		// Replace FILE path with absolute path
-		if filePattern.MatchString(line) {
+		// Replace FILE path with absolute path, keeping the declared file type
+		if matches := filePattern.FindStringSubmatch(line); matches != nil {
 			absFlacPath, _ := filepath.Abs(flacPath)
-			line = fmt.Sprintf(`FILE "%s" WAVE`, absFlacPath)
+			line = fmt.Sprintf(`FILE "%s" %s`, absFlacPath, matches[2])
+		}
+
+		if matches := indexPattern.FindStringSubmatch(line); matches != nil && strings.Count(matches[4], ":") == 3 {
+			if frames, ok := cueTimeToFrames(matches[4]); ok {
+				line = matches[1] + matches[2] + matches[3] + formatCueTime(float64(frames)/75)
+			}
+		}
+
+		if mode == BoundaryIndex00 {
+			if trackPattern.MatchString(line) {
+				pregap = ""
+			} else if matches := indexPattern.FindStringSubmatch(line); matches != nil {
+				switch matches[2] {
+				case "00":
+					pregap = matches[4]
+					continue
+				case "01":
+					if pregap != "" {
+						line = matches[1] + matches[2] + matches[3] + pregap
+					}
+				}
+			}
 		}
 
 		fmt.Fprintln(writer, line)
 	}
 
-	return scanner.Err()
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return writer.Flush()
 }
@@ -0,0 +1,131 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestRewriteCueLongTimecodes(t *testing.T) {
+	sheet := strings.Join([]string{
+		`FILE "concert.wav" WAVE`,
+		`  TRACK 01 AUDIO`,
+		`    INDEX 01 00:00:00`,
+		`  TRACK 02 AUDIO`,
+		`    INDEX 00 99:58:00`,
+		`    INDEX 01 99:59:74`,
+		`  TRACK 03 AUDIO`,
+		`    INDEX 00 01:44:58:00`,
+		`    INDEX 01 01:45:00:12`,
+	}, "\n")
+	flacPath := filepath.Join(t.TempDir(), "concert.flac")
+	file := fmt.Sprintf(`FILE "%s" WAVE`, flacPath)
+
+	tests := []struct {
+		name string
+		mode BoundaryMode
+		want []string
+	}{
+		{"index01", BoundaryIndex01, []string{
+			file,
+			`  TRACK 01 AUDIO`,
+			`    INDEX 01 00:00:00`,
+			`  TRACK 02 AUDIO`,
+			`    INDEX 00 99:58:00`,
+			`    INDEX 01 99:59:74`,
+			`  TRACK 03 AUDIO`,
+			`    INDEX 00 104:58:00`,
+			`    INDEX 01 105:00:12`,
+		}},
+		{"index00", BoundaryIndex00, []string{
+			file,
+			`  TRACK 01 AUDIO`,
+			`    INDEX 01 00:00:00`,
+			`  TRACK 02 AUDIO`,
+			`    INDEX 01 99:58:00`,
+			`  TRACK 03 AUDIO`,
+			`    INDEX 01 104:58:00`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := rewriteCue(strings.NewReader(sheet), &out, flacPath, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.Join(tt.want, "\n") + "\n"; out.String() != want {
+				t.Errorf("rewriteCue() =\n%s\nwant\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestCopyCueFileUTF16(t *testing.T) {
+	lines := []string{
+		`PERFORMER "Sigur Rós"`,
+		`TITLE "Ágætis byrjun"`,
+		`FILE "album.wav" WAVE`,
+		`  TRACK 01 AUDIO`,
+		`    TITLE "Intro"`,
+		`    INDEX 01 00:00:00`,
+		`  TRACK 02 AUDIO`,
+		`    TITLE "Svefn-g-englar"`,
+		`    INDEX 00 01:35:10`,
+		`    INDEX 01 01:37:00`,
+	}
+	dir := t.TempDir()
+	flacPath := filepath.Join(dir, "album.flac")
+
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			units := utf16.Encode([]rune("\uFEFF" + strings.Join(lines, "\r\n") + "\r\n"))
+			var data []byte
+			for _, unit := range units {
+				data = order.AppendUint16(data, unit)
+			}
+			srcPath := filepath.Join(t.TempDir(), "album.cue")
+			if err := os.WriteFile(srcPath, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			copyPath, err := copyCueFile(srcPath, dir, flacPath, BoundaryIndex00)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(copyPath)
+			got, err := os.ReadFile(copyPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// shnsplit gets plain UTF-8 without a BOM, with FILE pointing at
+			// the FLAC and track 2 starting at its INDEX 00
+			want := slices.Concat([]string{lines[0], lines[1], fmt.Sprintf(`FILE "%s" WAVE`, flacPath)},
+				lines[3:8], []string{`    INDEX 01 01:35:10`})
+			if string(got) != strings.Join(want, "\n")+"\n" {
+				t.Errorf("copyCueFile() wrote\n%q\nwant\n%q", got, strings.Join(want, "\n")+"\n")
+			}
+		})
+	}
+}
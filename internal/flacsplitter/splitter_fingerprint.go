@@ -0,0 +1,70 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-flac/flacvorbis"
+)
+
+// fpcalcCommand is the Chromaprint tool that computes AcoustID fingerprints
+const fpcalcCommand = "fpcalc"
+
+// trackFingerprint returns the Chromaprint fingerprint fpcalc computes for
+// the audio file at path
+func trackFingerprint(path string, opts *SplitOptions) (string, error) {
+	output, err := runExternal(opts, fpcalcCommand, path)
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v: %s", fpcalcCommand, err, bytes.TrimSpace(output))
+	}
+
+	// fpcalc prints DURATION=... and FINGERPRINT=... lines
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(nil, len(output)+1)
+	for scanner.Scan() {
+		if fingerprint, ok := strings.CutPrefix(scanner.Text(), "FINGERPRINT="); ok {
+			return fingerprint, nil
+		}
+	}
+	return "", fmt.Errorf("%s printed no fingerprint", fpcalcCommand)
+}
+
+// withFingerprint returns fill extended with the fingerprint of the track at
+// path, or fill unchanged, after logging why, when it cannot be computed
+func withFingerprint(fill func(cmts *flacvorbis.MetaDataBlockVorbisComment), path string,
+	opts *SplitOptions) func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+	fingerprint, err := trackFingerprint(path, opts)
+	if err != nil {
+		log.Printf("  Warning: Failed to fingerprint %s: %v", filepath.Base(path), err)
+		return fill
+	}
+
+	mapping := opts.TagMapping
+	if mapping == nil {
+		mapping = DefaultTagMapping()
+	}
+	return func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+		fill(cmts)
+		for _, name := range mapping[FieldFingerprint] {
+			cmts.Add(name, fingerprint)
+		}
+	}
+}
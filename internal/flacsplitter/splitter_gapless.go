@@ -0,0 +1,112 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"hash"
+
+	"github.com/mewkiz/flac/meta"
+)
+
+// sampleRange is a half-open range [start, end) of samples per channel
+type sampleRange struct {
+	start, end uint64
+}
+
+// verifyGapless checks that the written track ranges are contiguous, cover
+// the whole decoded stream, and hash to the source STREAMINFO MD5 signature.
+// The encoder never pads a final frame, so each track holds exactly its range.
+func verifyGapless(samples [][]int32, ranges []sampleRange, info *meta.StreamInfo) error {
+	if err := checkCoverage(ranges, uint64(len(samples[0]))); err != nil {
+		return fmt.Errorf("gapless check failed: %w", err)
+	}
+
+	// An all-zero signature means the source encoder did not compute one
+	if info.MD5sum == [16]byte{} {
+		return nil
+	}
+
+	h := md5.New()
+	for _, r := range ranges {
+		hashSamples(h, samples, r.start, r.end, info.BitsPerSample)
+	}
+	if !bytes.Equal(h.Sum(nil), info.MD5sum[:]) {
+		return fmt.Errorf("gapless check failed: MD5 of concatenated tracks does not match the source")
+	}
+
+	return nil
+}
+
+// checkCoverage checks that ranges, in order, cover samples 0 to total
+// without a gap or an overlap
+func checkCoverage(ranges []sampleRange, total uint64) error {
+	var next uint64
+	for _, r := range ranges {
+		if r.start > next {
+			return fmt.Errorf("samples %d-%d are not covered by any track", next, r.start)
+		}
+		if r.start < next {
+			return fmt.Errorf("samples %d-%d are in more than one track", r.start, next)
+		}
+		next = r.end
+	}
+	if next != total {
+		return fmt.Errorf("samples %d-%d are not covered by any track", next, total)
+	}
+	return nil
+}
+
+// checkGaplessOptions rejects the options that leave out or change audio,
+// which gapless verification cannot be combined with
+func checkGaplessOptions(opts *SplitOptions) error {
+	if !opts.Gapless {
+		return nil
+	}
+	switch {
+	case opts.Tracks != nil:
+		return fmt.Errorf("gapless verification needs every track and cannot be combined with a track selection")
+	case opts.PregapMode == PregapInsertSilence:
+		return fmt.Errorf("gapless verification cannot be combined with inserted PREGAP/POSTGAP silence")
+	case opts.Normalize != NormalizeOff:
+		return fmt.Errorf("gapless verification cannot be combined with normalization")
+	case opts.BoundaryMode == BoundaryDropGaps:
+		return fmt.Errorf("gapless verification cannot be combined with dropped INDEX 00 gaps")
+	}
+	return nil
+}
+
+// hashSamples writes interleaved little-endian samples to h in the layout
+// used for the FLAC STREAMINFO MD5 signature
+func hashSamples(h hash.Hash, samples [][]int32, start, end uint64, bitsPerSample uint8) {
+	bytesPerSample := (int(bitsPerSample) + 7) / 8
+	buf := make([]byte, 0, 4096)
+
+	for i := start; i < end; i++ {
+		for ch := range samples {
+			sample := samples[ch][i]
+			for b := 0; b < bytesPerSample; b++ {
+				buf = append(buf, byte(sample>>(8*b)))
+			}
+		}
+		if len(buf) >= 4000 {
+			h.Write(buf)
+			buf = buf[:0]
+		}
+	}
+	h.Write(buf)
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitGaplessRejoinsToSource(t *testing.T) {
+	cue, flacPath, source := writeTestAlbum(t, 3,
+		"  TRACK 01 AUDIO", "    TITLE \"One\"", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    TITLE \"Two\"", "    INDEX 00 00:00:50", "    INDEX 01 00:01:07",
+		"  TRACK 03 AUDIO", "    TITLE \"Three\"", "    INDEX 01 00:02:13",
+	)
+	opts := testOptions(t)
+	opts.Gapless = true
+
+	if err := Split(cue, flacPath, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks := outputTracks(t, opts.OutputDir)
+	if len(tracks) != 3 {
+		t.Fatalf("got %d tracks, want 3: %q", len(tracks), tracks)
+	}
+	var parts [][][]int32
+	for _, track := range tracks {
+		samples, _ := readTestFlac(t, track)
+		parts = append(parts, samples)
+	}
+	joined := concatSamples(parts...)
+
+	_, info := readTestFlac(t, flacPath)
+	if got := samplesMD5(joined); got != info.MD5sum {
+		t.Errorf("MD5 of the joined tracks = %x, want the source's %x", got, info.MD5sum)
+	}
+	for ch := range source {
+		if !slices.Equal(joined[ch], source[ch]) {
+			t.Errorf("channel %d of the joined tracks differs from the source", ch)
+		}
+	}
+}
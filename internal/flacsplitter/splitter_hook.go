@@ -0,0 +1,48 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// TrackHookFunc is called with every track file of cue once it is written
+// and tagged
+type TrackHookFunc func(cue cueparser.CueFile, track cueparser.Track, path string) error
+
+// errTrackHook marks a failed SplitOptions.TrackHook that TrackHookFatal
+// makes end the album
+var errTrackHook = errors.New("track hook failed")
+
+// runTrackHook calls opts.TrackHook, if set, for a finished track. A failure
+// is logged and nil returned, unless opts.TrackHookFatal is set.
+func runTrackHook(cue cueparser.CueFile, track cueparser.Track, path string, opts *SplitOptions) error {
+	if opts.TrackHook == nil {
+		return nil
+	}
+	err := opts.TrackHook(cue, track, path)
+	if err == nil {
+		return nil
+	}
+	if opts.TrackHookFatal {
+		return fmt.Errorf("%w for track %d: %v", errTrackHook, track.Number, err)
+	}
+	log.Printf("  Warning: Track hook failed for track %d: %v", track.Number, err)
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// id3v2HeaderSize is the size of an ID3v2 tag header (and of its optional footer)
+const id3v2HeaderSize = 10
+
+// ID3v2 tag header flags
+const (
+	id3v2Unsync         = 0x80 // unsynchronisation applied
+	id3v2ExtendedHeader = 0x40 // an extended header follows the header
+	id3v2Footer         = 0x10 // ID3v2.4 footer present
+)
+
+// id3v2TagSize returns the total size of the ID3v2 tag at the start of
+// header, or 0 if header does not begin with one
+func id3v2TagSize(header []byte) int64 {
+	if len(header) < id3v2HeaderSize || !bytes.HasPrefix(header, []byte("ID3")) {
+		return 0
+	}
+	size := int64(synchsafe(header[6:10])) + id3v2HeaderSize
+	if header[5]&id3v2Footer != 0 {
+		size += id3v2HeaderSize
+	}
+	return size
+}
+
+// synchsafe decodes a 28-bit ID3v2 synchsafe integer
+func synchsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
+
+// skipID3v2 positions r just past any ID3v2 tags that precede the fLaC
+// marker and returns the number of bytes skipped
+func skipID3v2(r io.ReadSeeker) (int64, error) {
+	var offset int64
+	header := make([]byte, id3v2HeaderSize)
+	for {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(r, header); err != nil {
+			break
+		}
+		size := id3v2TagSize(header)
+		if size == 0 {
+			break
+		}
+		offset += size
+	}
+
+	_, err := r.Seek(offset, io.SeekStart)
+	return offset, err
+}
+
+// readID3v2Text reads the text frames of an ID3v2.3 or ID3v2.4 tag at the
+// start of path, keyed by frame ID (e.g. TALB); nil means there is no tag
+func readID3v2Text(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, id3v2HeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, nil
+	}
+	size := id3v2TagSize(header)
+	version := header[3]
+	if size == 0 || (version != 3 && version != 4) {
+		return nil, nil
+	}
+
+	body := make([]byte, synchsafe(header[6:10]))
+	if _, err := io.ReadFull(file, body); err != nil {
+		return nil, err
+	}
+
+	flags := header[5]
+	if version == 3 && flags&id3v2Unsync != 0 {
+		// ID3v2.3 unsynchronises the whole tag, extended header included;
+		// ID3v2.4 does it per frame, so frame sizes count the stored bytes
+		body = removeUnsync(body)
+	}
+	if flags&id3v2ExtendedHeader != 0 && len(body) >= 4 {
+		// The ID3v2.3 size excludes its own 4 bytes, the ID3v2.4 one doesn't
+		extSize := uint64(binary.BigEndian.Uint32(body[:4])) + 4
+		if version == 4 {
+			extSize = uint64(synchsafe(body[:4]))
+		}
+		body = body[min(extSize, uint64(len(body))):]
+	}
+
+	frames := make(map[string]string)
+	for len(body) >= id3v2HeaderSize && body[0] != 0 {
+		id := string(body[:4])
+		frameSize := binary.BigEndian.Uint32(body[4:8])
+		if version == 4 {
+			frameSize = synchsafe(body[4:8])
+		}
+		frameFlags := body[9]
+		body = body[id3v2HeaderSize:]
+		if uint64(frameSize) > uint64(len(body)) {
+			break
+		}
+
+		if strings.HasPrefix(id, "T") && id != "TXXX" {
+			data, ok := id3FrameData(body[:frameSize], version, frameFlags, flags&id3v2Unsync != 0)
+			if ok && len(data) > 0 {
+				if text := decodeID3Text(data); text != "" {
+					frames[id] = text
+				}
+			}
+		}
+		body = body[frameSize:]
+	}
+	return frames, nil
+}
+
+// id3FrameData returns the contents of an ID3v2 frame given its format
+// flags, skipping the grouping byte and ID3v2.4 data length indicator and
+// undoing ID3v2.4 unsynchronisation (tagUnsync is the tag header's flag).
+// ok is false for compressed or encrypted frames, which are not decoded.
+func id3FrameData(data []byte, version, flags byte, tagUnsync bool) (contents []byte, ok bool) {
+	var compressed, encrypted, grouped, dataLength, unsync bool
+	if version == 3 {
+		compressed, encrypted, grouped = flags&0x80 != 0, flags&0x40 != 0, flags&0x20 != 0
+	} else {
+		grouped, compressed, encrypted = flags&0x40 != 0, flags&0x08 != 0, flags&0x04 != 0
+		unsync, dataLength = flags&0x02 != 0 || tagUnsync, flags&0x01 != 0
+	}
+	if compressed || encrypted {
+		return nil, false
+	}
+
+	skip := 0
+	if grouped {
+		skip++
+	}
+	if dataLength {
+		skip += 4
+	}
+	if skip > len(data) {
+		return nil, false
+	}
+	data = data[skip:]
+
+	if unsync {
+		data = removeUnsync(data)
+	}
+	return data, true
+}
+
+// removeUnsync undoes ID3v2 unsynchronisation, which inserts a zero byte
+// after every 0xFF
+func removeUnsync(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// decodeID3Text decodes the first value of an ID3v2 text frame, whose first
+// byte selects the encoding
+func decodeID3Text(data []byte) string {
+	encoding, text := data[0], data[1:]
+	var s string
+	switch encoding {
+	case 1, 2:
+		// UTF-16 with a BOM, or UTF-16BE without one
+		order := binary.ByteOrder(binary.BigEndian)
+		if encoding == 1 && len(text) >= 2 {
+			if text[0] == 0xff && text[1] == 0xfe {
+				order = binary.LittleEndian
+			}
+			text = text[2:]
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			units = append(units, order.Uint16(text[i:]))
+		}
+		s = string(utf16.Decode(units))
+	case 3:
+		s = string(text)
+	default:
+		// ISO-8859-1 maps directly onto the first 256 code points
+		runes := make([]rune, len(text))
+		for i, b := range text {
+			runes[i] = rune(b)
+		}
+		s = string(runes)
+	}
+
+	// ID3v2.4 separates multiple values with NUL
+	s, _, _ = strings.Cut(s, "\x00")
+	return strings.TrimSpace(s)
+}
+
+// fillFromID3 fills album fields the CUE sheet lacks from an ID3v2 tag
+// prepended to the audio file
+func fillFromID3(cue *cueparser.CueFile, audioPath string) {
+	frames, err := readID3v2Text(audioPath)
+	if err != nil {
+		log.Printf("  Warning: Failed to read ID3v2 tag: %v", err)
+		return
+	}
+	if frames == nil {
+		return
+	}
+	log.Printf("  Skipping ID3v2 tag before the fLaC marker")
+
+	var filled []string
+	fill := func(field *string, name string, ids ...string) {
+		if *field != "" {
+			return
+		}
+		for _, id := range ids {
+			if v := frames[id]; v != "" {
+				*field = v
+				filled = append(filled, name)
+				return
+			}
+		}
+	}
+	fill(&cue.Album, "album", "TALB")
+	fill(&cue.Performer, "artist", "TPE2", "TPE1")
+	fill(&cue.Genre, "genre", "TCON")
+	fill(&cue.Date, "date", "TDRC", "TYER")
+
+	if len(filled) > 0 {
+		log.Printf("  Using ID3v2 tag for fields missing from the CUE sheet: %s", strings.Join(filled, ", "))
+	}
+}
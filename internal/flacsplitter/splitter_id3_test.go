@@ -0,0 +1,115 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// synchsafeBytes encodes n as a 28-bit ID3v2 synchsafe integer
+func synchsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+// addUnsync applies ID3v2 unsynchronisation, inserting a zero after every 0xFF
+func addUnsync(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff}, []byte{0xff, 0x00})
+}
+
+// id3Frame builds an ID3v2 frame whose size is that of the stored data
+func id3Frame(version byte, id string, flags byte, data []byte) []byte {
+	size := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	if version == 4 {
+		size = synchsafeBytes(len(data))
+	}
+	frame := append([]byte(id), size...)
+	return append(append(frame, 0, flags), data...)
+}
+
+// id3Tag builds an ID3v2 tag around body
+func id3Tag(version, flags byte, body []byte) []byte {
+	tag := append([]byte{'I', 'D', '3', version, 0, flags}, synchsafeBytes(len(body))...)
+	return append(tag, body...)
+}
+
+// latin1Text is an ISO-8859-1 text frame body for "Café ÿ", whose 0xFF
+// needs unsynchronisation
+var latin1Text = []byte{0, 'C', 'a', 'f', 0xe9, ' ', 0xff}
+
+func TestReadID3v2Text(t *testing.T) {
+	want := map[string]string{"TALB": "Café ÿ", "TPE1": "Artist"}
+	artist := append([]byte{3}, "Artist"...)
+
+	// An ID3v2.4 data length indicator precedes the frame contents
+	withLength := func(data []byte) []byte {
+		return append(binary.BigEndian.AppendUint32(nil, uint32(len(data))), data...)
+	}
+
+	tests := []struct {
+		name string
+		tag  []byte
+		want map[string]string
+	}{
+		{"v2.3", id3Tag(3, 0, slices.Concat(
+			id3Frame(3, "TALB", 0, latin1Text),
+			id3Frame(3, "TPE1", 0, artist),
+		)), want},
+		{"v2.3 extended header and unsynchronisation", id3Tag(3, id3v2Unsync|id3v2ExtendedHeader, addUnsync(slices.Concat(
+			[]byte{0, 0, 0, 6, 0, 0, 0xff, 0xff, 0xff, 0xff},
+			id3Frame(3, "TALB", 0, latin1Text),
+			id3Frame(3, "TPE1", 0, artist),
+		))), want},
+		{"v2.3 compressed frame", id3Tag(3, 0, slices.Concat(
+			id3Frame(3, "TALB", 0x80, latin1Text),
+			id3Frame(3, "TPE1", 0, artist),
+		)), map[string]string{"TPE1": "Artist"}},
+		{"v2.4", id3Tag(4, 0, slices.Concat(
+			id3Frame(4, "TALB", 0, latin1Text),
+			id3Frame(4, "TPE1", 0, artist),
+		)), want},
+		{"v2.4 extended header and unsynchronised frames", id3Tag(4, id3v2Unsync|id3v2ExtendedHeader, slices.Concat(
+			[]byte{0, 0, 0, 6, 1, 0},
+			id3Frame(4, "TALB", 0x03, withLength(addUnsync(latin1Text))),
+			id3Frame(4, "TPE1", 0x02, artist),
+		)), want},
+		{"v2.4 grouped and encrypted frames", id3Tag(4, 0, slices.Concat(
+			id3Frame(4, "TALB", 0x40, append([]byte{7}, latin1Text...)),
+			id3Frame(4, "TPE1", 0x04, artist),
+		)), map[string]string{"TALB": "Café ÿ"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "album.flac")
+			if err := os.WriteFile(path, append(tt.tag, "fLaC"...), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readID3v2Text(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("readID3v2Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// writeManifest writes "<album>.sha256" into the output directory, listing
+// the SHA256 of every file produced for cue in sha256sum format. The files
+// are hashed once complete, since tagging rewrites them after encoding.
+func writeManifest(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
+	files := outputFiles(cue, flacPath, opts)
+	if len(files) == 0 {
+		return nil
+	}
+
+	album := chapterFilename(cue, flacPath, opts)
+	name := strings.TrimSuffix(album, filepath.Ext(album)) + ".sha256"
+	manifestPath := filepath.Join(opts.OutputDir, name)
+
+	var lines []string
+	for _, file := range files {
+		sum, err := hashFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", file, err)
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s", sum, filepath.Base(file)))
+	}
+
+	if err := writeFileAtomic(manifestPath, func(ws io.WriteSeeker) error {
+		w := bufio.NewWriter(ws)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+		return w.Flush()
+	}); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	log.Printf("  Manifest written: %s (%d files)", manifestPath, len(lines))
+	return nil
+}
+
+// outputFiles returns the existing output files of a split of cue
+func outputFiles(cue cueparser.CueFile, flacPath string, opts *SplitOptions) []string {
+	var files []string
+	for _, path := range plannedOutputs(cue, flacPath, opts) {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// hashFile returns the hex-encoded SHA256 of a file's contents
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mewkiz/flac/meta"
+)
+
+// ErrDecodeTooLarge is returned when decoding an album in memory would take
+// more than SplitOptions.MaxDecodeBytes
+var ErrDecodeTooLarge = errors.New("decoded audio would exceed the memory limit")
+
+// decodeBytes estimates the memory pure Go mode needs to hold the decoded
+// stream: one int32 per sample and channel
+func decodeBytes(info *meta.StreamInfo) uint64 {
+	return info.NSamples * uint64(info.NChannels) * 4
+}
+
+// checkDecodeMemory refuses streams whose decoded samples would exceed
+// opts.MaxDecodeBytes. Streams that do not record their length cannot be
+// estimated and are let through.
+func checkDecodeMemory(info *meta.StreamInfo, opts *SplitOptions) error {
+	if opts.MaxDecodeBytes == 0 {
+		return nil
+	}
+	if info.NSamples == 0 {
+		log.Printf("  Warning: Cannot check the memory needed to decode a stream of unknown length")
+		return nil
+	}
+	if need := decodeBytes(info); need > opts.MaxDecodeBytes {
+		return fmt.Errorf("%w: about %d MB needed, limit %d MB; use --hybrid or --external to split without decoding in memory",
+			ErrDecodeTooLarge, need>>20, opts.MaxDecodeBytes>>20)
+	}
+	return nil
+}
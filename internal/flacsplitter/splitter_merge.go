@@ -0,0 +1,274 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+	"github.com/mewkiz/flac/meta"
+)
+
+// MergeTracks is the inverse of Split: it decodes the FLAC tracks in paths,
+// in that order, concatenates their audio into one FLAC file at outputFlac
+// and writes a CUE sheet beside it with the same name and a .cue extension.
+// Each INDEX 01 is the cumulative length of the tracks before it, and the
+// CUE metadata comes from the tracks' Vorbis comments, read back through
+// opts.TagMapping. The tracks must share their sample rate, channel count
+// and bit depth. OpenReader, NewWriter, BlockSize, MaxDecodeBytes,
+// OverwriteFiles and Progress apply as in pure Go mode.
+func MergeTracks(paths []string, outputFlac string, opts *SplitOptions) (cueparser.CueFile, error) {
+	cue := cueparser.CueFile{
+		Path:          strings.TrimSuffix(outputFlac, filepath.Ext(outputFlac)) + ".cue",
+		AudioFile:     filepath.Base(outputFlac),
+		AudioFileType: "WAVE",
+		CustomFields:  make(map[string]string),
+	}
+	if len(paths) == 0 {
+		return cue, fmt.Errorf("no tracks to merge")
+	}
+	if err := validatePadding(opts); err != nil {
+		return cue, err
+	}
+	if !opts.OverwriteFiles {
+		for _, path := range []string{outputFlac, cue.Path} {
+			if _, err := os.Stat(path); err == nil {
+				return cue, fmt.Errorf("%w: %s", errOutputExists, path)
+			}
+		}
+	}
+
+	openReader := opts.OpenReader
+	if openReader == nil {
+		openReader = OpenFlacReader
+	}
+	newWriter := opts.NewWriter
+	if newWriter == nil {
+		newWriter = NewFlacWriter
+	}
+
+	// Open every track first, so a mismatched format, an invalid block size
+	// or a merge too large for MaxDecodeBytes fails before any decoding
+	streams := make([]AudioReader, 0, len(paths))
+	defer func() {
+		for _, stream := range streams {
+			stream.Close()
+		}
+	}()
+	var info *meta.StreamInfo
+	var totalSamples uint64
+	known := true
+	for _, path := range paths {
+		stream, err := openReader(path)
+		if err != nil {
+			return cue, fmt.Errorf("%s: failed to open FLAC file: %v", filepath.Base(path), err)
+		}
+		streams = append(streams, stream)
+		trackInfo := stream.Info()
+		if info == nil {
+			info = trackInfo
+		} else if trackInfo.SampleRate != info.SampleRate || trackInfo.NChannels != info.NChannels ||
+			trackInfo.BitsPerSample != info.BitsPerSample {
+			return cue, fmt.Errorf("%s: %d Hz, %d channels, %d bits does not match the first track (%d Hz, %d channels, %d bits)",
+				filepath.Base(path), trackInfo.SampleRate, trackInfo.NChannels, trackInfo.BitsPerSample,
+				info.SampleRate, info.NChannels, info.BitsPerSample)
+		}
+		totalSamples += trackInfo.NSamples
+		known = known && trackInfo.NSamples > 0
+	}
+
+	blockSize := opts.BlockSize
+	if blockSize == 0 {
+		blockSize = DefaultBlockSize
+	}
+	if err := validateBlockSize(blockSize, info.SampleRate, opts.NonSubset); err != nil {
+		return cue, err
+	}
+	merged := *info
+	merged.NSamples = 0
+	if known {
+		merged.NSamples = totalSamples
+	}
+	if err := checkDecodeMemory(&merged, opts); err != nil {
+		return cue, err
+	}
+
+	// Decode every track into one buffer, recording where each one starts
+	samples := make([][]int32, info.NChannels)
+	starts := make([]uint64, len(paths))
+	for i, stream := range streams {
+		track, err := readAllSamples(stream, nil)
+		if err != nil {
+			return cue, fmt.Errorf("%s: failed to read FLAC samples: %v", filepath.Base(paths[i]), err)
+		}
+		starts[i] = uint64(len(samples[0]))
+		for ch := range samples {
+			samples[ch] = append(samples[ch], track[ch]...)
+		}
+		if opts.Progress != nil {
+			opts.Progress(uint64(i+1), uint64(len(paths)))
+		}
+	}
+	totalSamples = uint64(len(samples[0]))
+
+	fillMergedCue(&cue, paths, starts, info.SampleRate, opts)
+
+	log.Printf("  Encoding %d tracks (%s) into %s", len(paths),
+		FormatDuration(float64(totalSamples)/float64(info.SampleRate)), outputFlac)
+	err := writeFileAtomic(outputFlac, func(ws io.WriteSeeker) error {
+		return encodeFlac(ws, samples, sampleRange{0, totalSamples}, info, newWriter, blockSize, nil)
+	})
+	if err != nil {
+		return cue, err
+	}
+
+	// The merged file carries the album tags and the first track's pictures
+	pictures, err := readPictures(paths[0])
+	if err != nil {
+		log.Printf("  Warning: Failed to read embedded pictures: %v", err)
+	}
+	err = updateVorbisComment(outputFlac, pictures, opts.PaddingBytes, func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+		addMappedTags(cmts, albumFileValues(cue, uint8(info.NChannels), opts), cue, nil, opts)
+	})
+	if err != nil {
+		log.Printf("  Warning: Failed to write tags: %v", err)
+	}
+
+	err = writeFileAtomic(cue.Path, func(ws io.WriteSeeker) error {
+		return cueparser.Write(ws, &cue)
+	})
+	if err != nil {
+		return cue, err
+	}
+
+	log.Printf("  Merged %d tracks: %s", len(paths), cue.Path)
+	return cue, nil
+}
+
+// fillMergedCue adds a track to cue for every merged file, starting at its
+// offset in samples, and takes the album metadata from the first file that
+// has each value
+func fillMergedCue(cue *cueparser.CueFile, paths []string, starts []uint64, sampleRate uint32, opts *SplitOptions) {
+	mapping := opts.TagMapping
+	if mapping == nil {
+		mapping = DefaultTagMapping()
+	}
+
+	var artists []string
+	for i, path := range paths {
+		cmts, err := readVorbisComment(path)
+		if err != nil {
+			log.Printf("  Warning: Failed to read tags of %s: %v", filepath.Base(path), err)
+			cmts = flacvorbis.New()
+		}
+		tag := func(field string) string {
+			for _, name := range mapping[field] {
+				if values, _ := cmts.Get(name); len(values) > 0 && values[0] != "" {
+					return values[0]
+				}
+			}
+			return ""
+		}
+		isrc, _ := cmts.Get("ISRC")
+
+		// CUE timecodes count CD frames, so offsets between frames are rounded
+		if starts[i]*75%uint64(sampleRate) != 0 {
+			log.Printf("  Warning: Track %d does not start on a CD frame, its INDEX 01 is rounded", i+1)
+		}
+		cue.Tracks = append(cue.Tracks, cueparser.Track{
+			Number:              i + 1,
+			Type:                "AUDIO",
+			Title:               tag(FieldTitle),
+			Performer:           tag(FieldArtist),
+			Composer:            tag(FieldComposer),
+			Songwriter:          tag(FieldSongwriter),
+			ISRC:                cmp.Or(isrc...),
+			Index:               formatCueTime(float64(starts[i]) / float64(sampleRate)),
+			ReplayGainTrackGain: tag(FieldTrackGain),
+			ReplayGainTrackPeak: tag(FieldTrackPeak),
+			CustomFields:        make(map[string]string),
+		})
+		artists = append(artists, tag(FieldArtist))
+
+		fill := func(dst *string, field string) {
+			if *dst == "" {
+				*dst = tag(field)
+			}
+		}
+		fill(&cue.Album, FieldAlbum)
+		fill(&cue.Performer, FieldAlbumArtist)
+		fill(&cue.Date, FieldDate)
+		fill(&cue.Genre, FieldGenre)
+		fill(&cue.Comment, FieldComment)
+		fill(&cue.Catalog, FieldCatalog)
+		fill(&cue.DiscID, FieldDiscID)
+		fill(&cue.DiscNumber, FieldDiscNumber)
+		fill(&cue.TotalDiscs, FieldTotalDiscs)
+		fill(&cue.ReplayGainAlbumGain, FieldAlbumGain)
+		fill(&cue.ReplayGainAlbumPeak, FieldAlbumPeak)
+	}
+
+	// Without an album artist tag, an artist shared by every track stands in
+	if cue.Performer == "" {
+		cue.Performer = artists[0]
+		for _, artist := range artists {
+			if artist != cue.Performer {
+				cue.Performer = ""
+				break
+			}
+		}
+	}
+
+	// A "3/12" DISCNUMBER holds the total as well
+	if number, total, ok := strings.Cut(cue.DiscNumber, "/"); ok {
+		cue.DiscNumber = number
+		cue.TotalDiscs = cmp.Or(cue.TotalDiscs, total)
+	}
+	if len(cue.Date) >= 4 {
+		cue.Year = cue.Date[:4]
+	}
+}
+
+// readVorbisComment returns the Vorbis comments of a FLAC file without
+// reading its audio (empty when it has none)
+func readVorbisComment(flacPath string) (*flacvorbis.MetaDataBlockVorbisComment, error) {
+	file, err := os.Open(flacPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := skipID3v2(file); err != nil {
+		return nil, err
+	}
+	f, err := flac.ParseMetadata(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FLAC metadata: %v", err)
+	}
+	for _, block := range f.Meta {
+		if block.Type == flac.VorbisComment {
+			return flacvorbis.ParseFromMetaDataBlock(*block)
+		}
+	}
+	return flacvorbis.New(), nil
+}
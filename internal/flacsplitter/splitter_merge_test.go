@@ -0,0 +1,79 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// blockCountingReader counts the blocks decoded through it
+type blockCountingReader struct {
+	AudioReader
+	blocks *int
+}
+
+func (r blockCountingReader) ReadBlock() ([][]int32, error) {
+	*r.blocks++
+	return r.AudioReader.ReadBlock()
+}
+
+func TestMergeTracksChecksBeforeDecoding(t *testing.T) {
+	dir := t.TempDir()
+	samples := testSignal(2, 10000)
+	paths := []string{filepath.Join(dir, "01.flac"), filepath.Join(dir, "02.flac")}
+	for _, path := range paths {
+		writeTestFlac(t, path, samples)
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(opts *SplitOptions)
+		wantErr error
+	}{
+		// Each track fits on its own, only the merged audio does not
+		{"memory limit", func(opts *SplitOptions) { opts.MaxDecodeBytes = 100000 }, ErrDecodeTooLarge},
+		{"block size", func(opts *SplitOptions) { opts.BlockSize = 8 }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := 0
+			opts := testOptions(t)
+			opts.OpenReader = func(path string) (AudioReader, error) {
+				r, err := OpenFlacReader(path)
+				if err != nil {
+					return nil, err
+				}
+				return blockCountingReader{r, &blocks}, nil
+			}
+			tt.setup(opts)
+
+			merged := filepath.Join(opts.OutputDir, "merged.flac")
+			_, err := MergeTracks(paths, merged, opts)
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MergeTracks() error = %v, want %v", err, tt.wantErr)
+			}
+			if blocks != 0 {
+				t.Errorf("MergeTracks() decoded %d blocks before failing", blocks)
+			}
+			if _, err := os.Stat(merged); !os.IsNotExist(err) {
+				t.Errorf("MergeTracks() left %s behind: %v", merged, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// NormalizeMode selects how the decoded album is rescaled before encoding
+type NormalizeMode int
+
+const (
+	// NormalizeOff leaves the audio untouched
+	NormalizeOff NormalizeMode = iota
+	// NormalizePeak scales the album so its highest sample reaches the target in dBFS
+	NormalizePeak
+	// NormalizeLoudness scales the album so its integrated loudness
+	// (ITU-R BS.1770, EBU R128 gating) reaches the target in LUFS
+	NormalizeLoudness
+)
+
+// Default normalization targets, used when SplitOptions.NormalizeTarget is 0
+const (
+	DefaultPeakTarget     = -1.0  // dBFS
+	DefaultLoudnessTarget = -18.0 // LUFS, the ReplayGain 2.0 reference level
+)
+
+// normalizeAlbum applies one gain to every sample of the album so relative
+// dynamics between tracks are kept. Loudness gain is reduced when it would
+// clip the peak.
+func normalizeAlbum(samples [][]int32, sampleRate uint32, bitsPerSample uint8, mode NormalizeMode, target float64) error {
+	peak := samplePeak(samples, bitsPerSample)
+	if peak == 0 {
+		log.Printf("  Warning: Album is silent, skipping normalization")
+		return nil
+	}
+	peakDB := 20 * math.Log10(peak)
+
+	var gainDB float64
+	switch mode {
+	case NormalizePeak:
+		if target == 0 {
+			target = DefaultPeakTarget
+		}
+		if target > 0 {
+			return fmt.Errorf("peak normalization target must be at most 0 dBFS, got %.2f", target)
+		}
+		gainDB = target - peakDB
+		log.Printf("  Normalizing album peak from %.2f to %.2f dBFS (%+.2f dB)", peakDB, target, gainDB)
+
+	case NormalizeLoudness:
+		if target == 0 {
+			target = DefaultLoudnessTarget
+		}
+		loudness := integratedLoudness(samples, sampleRate, bitsPerSample)
+		if math.IsInf(loudness, -1) {
+			log.Printf("  Warning: Album is too quiet to measure, skipping normalization")
+			return nil
+		}
+		gainDB = target - loudness
+		if gainDB+peakDB > 0 {
+			log.Printf("  Warning: Limiting gain to %+.2f dB to avoid clipping (%.1f LUFS instead of %.1f)",
+				-peakDB, loudness-peakDB, target)
+			gainDB = -peakDB
+		}
+		log.Printf("  Normalizing album loudness from %.1f LUFS (%+.2f dB)", loudness, gainDB)
+
+	default:
+		return fmt.Errorf("unknown normalize mode: %d", mode)
+	}
+
+	applyGain(samples, math.Pow(10, gainDB/20), bitsPerSample)
+	return nil
+}
+
+// samplePeak returns the largest absolute sample relative to full scale
+func samplePeak(samples [][]int32, bitsPerSample uint8) float64 {
+	var peak int64
+	for _, channel := range samples {
+		for _, s := range channel {
+			peak = max(peak, abs64(int64(s)))
+		}
+	}
+	return float64(peak) / fullScale(bitsPerSample)
+}
+
+// applyGain scales every sample by gain, rounding and clamping to the bit depth
+func applyGain(samples [][]int32, gain float64, bitsPerSample uint8) {
+	hi := fullScale(bitsPerSample) - 1
+	lo := -fullScale(bitsPerSample)
+	for _, channel := range samples {
+		for i, s := range channel {
+			channel[i] = int32(math.Max(lo, math.Min(hi, math.Round(float64(s)*gain))))
+		}
+	}
+}
+
+// fullScale returns the magnitude of the most negative sample at the bit depth
+func fullScale(bitsPerSample uint8) float64 {
+	return float64(int64(1) << (bitsPerSample - 1))
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// BS.1770 gating: 400 ms blocks overlapping by 75%, an absolute gate at
+// -70 LUFS and a relative gate 10 LU below the absolute-gated loudness
+const (
+	loudnessBlockParts = 4 // 100 ms steps per 400 ms block
+	absoluteGate       = -70.0
+	relativeGate       = -10.0
+)
+
+// integratedLoudness measures the gated loudness of the album in LUFS, or
+// -Inf when no block passes the absolute gate
+func integratedLoudness(samples [][]int32, sampleRate uint32, bitsPerSample uint8) float64 {
+	step := int(sampleRate) / 10
+	if step == 0 || len(samples) == 0 {
+		return math.Inf(-1)
+	}
+	weights := channelWeights(len(samples))
+	scale := fullScale(bitsPerSample)
+
+	// Weighted energy of the K-weighted signal per 100 ms step
+	nSteps := len(samples[0]) / step
+	energy := make([]float64, nSteps)
+	for ch, channel := range samples {
+		if weights[ch] == 0 {
+			continue
+		}
+		filter := newKWeighting(float64(sampleRate))
+		for i := 0; i < nSteps*step; i++ {
+			y := filter.process(float64(channel[i]) / scale)
+			energy[i/step] += weights[ch] * y * y
+		}
+	}
+
+	var blocks []float64
+	for i := 0; i+loudnessBlockParts <= nSteps; i++ {
+		var sum float64
+		for _, e := range energy[i : i+loudnessBlockParts] {
+			sum += e
+		}
+		blocks = append(blocks, sum/float64(loudnessBlockParts*step))
+	}
+
+	gated := func(threshold float64) (float64, int) {
+		var sum float64
+		var n int
+		for _, z := range blocks {
+			if blockLoudness(z) > threshold {
+				sum += z
+				n++
+			}
+		}
+		if n == 0 {
+			return 0, 0
+		}
+		return sum / float64(n), n
+	}
+
+	mean, n := gated(absoluteGate)
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	if mean, n = gated(blockLoudness(mean) + relativeGate); n == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(mean)
+}
+
+// blockLoudness converts a weighted mean square to LUFS
+func blockLoudness(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
+
+// channelWeights returns the BS.1770 weight of each channel in FLAC channel
+// order: front channels count once, the LFE not at all and surrounds 1.41 times
+func channelWeights(nChannels int) []float64 {
+	weights := make([]float64, nChannels)
+	for ch := range weights {
+		weights[ch] = 1
+	}
+	switch {
+	case nChannels == 4: // FL FR BL BR
+		weights[2], weights[3] = 1.41, 1.41
+	case nChannels >= 5: // FL FR FC [LFE] BL BR ...
+		for ch := 3; ch < nChannels; ch++ {
+			weights[ch] = 1.41
+		}
+		if nChannels >= 6 {
+			weights[3] = 0
+		}
+	}
+	return weights
+}
+
+// kWeighting is the BS.1770 pre-filter: a high shelf followed by a high pass,
+// with coefficients derived for the sample rate
+type kWeighting struct {
+	shelf, highPass biquad
+}
+
+func newKWeighting(sampleRate float64) *kWeighting {
+	k := math.Tan(math.Pi * 1681.974450955533 / sampleRate)
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * 38.13547087602444 / sampleRate)
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return &kWeighting{shelf: shelf, highPass: highPass}
+}
+
+func (f *kWeighting) process(x float64) float64 {
+	return f.highPass.process(f.shelf.process(x))
+}
+
+// biquad is a direct form I second order IIR filter
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// withoutReplayGain returns cue without its ReplayGain values, which no
+// longer describe normalized audio
+func withoutReplayGain(cue cueparser.CueFile) cueparser.CueFile {
+	cue.ReplayGainAlbumGain, cue.ReplayGainAlbumPeak = "", ""
+	tracks := make([]cueparser.Track, len(cue.Tracks))
+	for i, track := range cue.Tracks {
+		track.ReplayGainTrackGain, track.ReplayGainTrackPeak = "", ""
+		tracks[i] = track
+	}
+	cue.Tracks = tracks
+	return cue
+}
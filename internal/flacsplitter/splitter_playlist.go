@@ -0,0 +1,134 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// PlaylistFormat selects the extension of the playlist written by WritePlaylist
+type PlaylistFormat string
+
+const (
+	// PlaylistM3U8 writes "<album>.m3u8" (default)
+	PlaylistM3U8 PlaylistFormat = "m3u8"
+	// PlaylistM3U writes "<album>.m3u" for players that only look for that
+	// extension; the content is UTF-8 all the same
+	PlaylistM3U PlaylistFormat = "m3u"
+)
+
+// playlistEntry is one track of an album playlist
+type playlistEntry struct {
+	path    string
+	title   string
+	seconds int // -1 when unknown
+}
+
+// writePlaylist writes an extended M3U playlist of the tracks produced for cue
+// into the output directory, in album order with #EXTINF durations taken from
+// the track boundaries. Tracks that were not written are left out.
+func writePlaylist(cue cueparser.CueFile, flacPath string, opts *SplitOptions) error {
+	entries := playlistEntries(cue, flacPath, opts)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ext := opts.PlaylistFormat
+	if ext == "" {
+		ext = PlaylistM3U8
+	}
+	album := chapterFilename(cue, flacPath, opts)
+	name := strings.TrimSuffix(album, filepath.Ext(album)) + "." + string(ext)
+	playlistPath := filepath.Join(opts.OutputDir, name)
+
+	if err := writeFileAtomic(playlistPath, func(ws io.WriteSeeker) error {
+		w := bufio.NewWriter(ws)
+		fmt.Fprintln(w, "#EXTM3U")
+		if cue.Album != "" {
+			fmt.Fprintf(w, "#PLAYLIST:%s\n", cue.Album)
+		}
+		for _, entry := range entries {
+			fmt.Fprintf(w, "#EXTINF:%d,%s\n", entry.seconds, entry.title)
+			fmt.Fprintln(w, entry.path)
+		}
+		return w.Flush()
+	}); err != nil {
+		return fmt.Errorf("failed to write playlist: %w", err)
+	}
+
+	log.Printf("  Playlist written: %s (%d tracks)", playlistPath, len(entries))
+	return nil
+}
+
+// playlistEntries returns the existing output tracks of a split of cue
+func playlistEntries(cue cueparser.CueFile, flacPath string, opts *SplitOptions) []playlistEntry {
+	// CD frames stand in for samples when the source cannot be probed, which
+	// leaves the length of the last track unknown
+	sampleRate, totalSamples := uint32(75), uint64(math.MaxUint64)
+	if detectAudioFormat(flacPath) == FormatFLAC {
+		if info, total, err := sourceLength(flacPath, opts); err == nil && info.SampleRate > 0 && total > 0 {
+			sampleRate, totalSamples = info.SampleRate, total
+		}
+	}
+
+	tracks := cue.Tracks
+	boundaries := CalculateBoundariesWithMode(cue, sampleRate, totalSamples, opts.BoundaryMode)
+	if opts.Mode == ModeGoAudioFull && len(boundaries) > 0 && boundaries[0].StartSample > 0 {
+		tracks, boundaries, _ = layoutHiddenTrack(cue, boundaries, opts)
+	}
+
+	var entries []playlistEntry
+	for i, track := range tracks {
+		if !opts.Tracks.Contains(track.Number) {
+			continue
+		}
+		path := trackOutputPath(track, opts)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(opts.OutputDir, path)
+		if err != nil {
+			rel = path
+		}
+
+		seconds := -1
+		if b := boundaries[i]; b.EndSample != math.MaxUint64 {
+			seconds = int(math.Round(float64(b.EndSample-b.StartSample) / float64(sampleRate)))
+		}
+
+		performer := track.Performer
+		if performer == "" {
+			performer = cue.Performer
+		}
+		title := track.Title
+		switch {
+		case title == "":
+			title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		case performer != "":
+			title = performer + " - " + title
+		}
+		entries = append(entries, playlistEntry{path: rel, title: title, seconds: seconds})
+	}
+	return entries
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os/exec"
+	"time"
+)
+
+// retryBackoff is the delay before the first retry of an external tool; it
+// doubles with every further attempt
+var retryBackoff = time.Second
+
+// transientErrors are tool output fragments of failures that may succeed on
+// a second try, such as I/O errors on flaky network mounts
+var transientErrors = [][]byte{
+	[]byte("Input/output error"),
+	[]byte("I/O error"),
+	[]byte("Resource temporarily unavailable"),
+	[]byte("Stale file handle"),
+	[]byte("Connection timed out"),
+	[]byte("Connection reset by peer"),
+	[]byte("Host is down"),
+}
+
+// runExternalRetry runs an external tool like runExternal, retrying up to
+// opts.MaxRetries times with exponential backoff while the failure looks
+// transient. It also returns the number of attempts made.
+func runExternalRetry(opts *SplitOptions, name string, args ...string) ([]byte, int, error) {
+	delay := retryBackoff
+	for attempt := 1; ; attempt++ {
+		output, err := runExternal(opts, name, args...)
+		if err == nil || attempt > opts.MaxRetries || !isTransient(err, output) {
+			return output, attempt, err
+		}
+
+		log.Printf("  Warning: %s failed (attempt %d of %d), retrying in %s: %v",
+			name, attempt, opts.MaxRetries+1, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isTransient reports whether a failed run may succeed when retried. Only
+// tools that ran and exited with an error whose output names a transient
+// condition qualify; timeouts, missing tools and errors such as "No such
+// file or directory" are final.
+func isTransient(err error, output []byte) bool {
+	var exitErr *exec.ExitError
+	if errors.Is(err, ErrExternalTimeout) || !errors.As(err, &exitErr) {
+		return false
+	}
+	for _, pattern := range transientErrors {
+		if bytes.Contains(output, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TrackRange is an inclusive range of track numbers; a To of 0 leaves the
+// range open-ended
+type TrackRange struct {
+	From, To int
+}
+
+// TrackSelection is a set of track numbers to split; a nil selection
+// selects every track
+type TrackSelection []TrackRange
+
+// ParseTrackSelection parses a comma-separated list of track numbers and
+// ranges such as "3-5,8,10-"
+func ParseTrackSelection(spec string) (TrackSelection, error) {
+	var sel TrackSelection
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("empty entry in track selection %q", spec)
+		}
+
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("invalid track number in %q", part)
+		}
+
+		r := TrackRange{From: first, To: first}
+		if isRange {
+			r.To = 0
+			if to = strings.TrimSpace(to); to != "" {
+				last, err := strconv.Atoi(to)
+				if err != nil || last < first {
+					return nil, fmt.Errorf("invalid track range %q", part)
+				}
+				r.To = last
+			}
+		}
+		sel = append(sel, r)
+	}
+	return sel, nil
+}
+
+// Contains reports whether track number n is selected
+func (s TrackSelection) Contains(n int) bool {
+	if s == nil {
+		return true
+	}
+	for _, r := range s {
+		if n >= r.From && (r.To == 0 || n <= r.To) {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the selection in the form accepted by ParseTrackSelection
+func (s TrackSelection) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		switch {
+		case r.To == 0:
+			parts[i] = fmt.Sprintf("%d-", r.From)
+		case r.To == r.From:
+			parts[i] = strconv.Itoa(r.From)
+		default:
+			parts[i] = fmt.Sprintf("%d-%d", r.From, r.To)
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// filterShortTracks applies opts.MinTrackDuration to cue. Merged tracks are
+// removed from cue; skipped tracks stay in it, so the other tracks keep
+// their boundaries, and are deselected in the returned copy of opts.
+func filterShortTracks(cue *cueparser.CueFile, audioPath string, opts *SplitOptions) (*SplitOptions, error) {
+	// The last track ends with the audio, which only FLAC sources can tell
+	audioSeconds := -1.0
+	if detectAudioFormat(audioPath) == FormatFLAC {
+		if seconds, err := probeFlacDuration(audioPath, opts); err == nil && seconds > 0 {
+			audioSeconds = seconds
+		}
+	}
+
+	var skipped []int
+	tracks := slices.Clone(cue.Tracks)
+	for i := 0; i < len(tracks); i++ {
+		duration, ok := trackDuration(tracks, i, audioSeconds, opts.BoundaryMode)
+		if !ok || duration >= opts.MinTrackDuration || len(tracks) < 2 {
+			continue
+		}
+
+		track := tracks[i]
+		switch opts.ShortTracks {
+		case ShortTrackSkip:
+			log.Printf("  Skipping track %d (%s), it is only %s long", track.Number, track.Title, FormatDuration(duration.Seconds()))
+			skipped = append(skipped, track.Number)
+		case ShortTrackMerge:
+			if i == 0 {
+				// The next track starts where the short one did
+				log.Printf("  Merging track %d (%s, %s) into track %d", track.Number, track.Title,
+					FormatDuration(duration.Seconds()), tracks[1].Number)
+				tracks[1].Index = track.Index
+				tracks[1].PreGap = track.PreGap
+			} else {
+				log.Printf("  Merging track %d (%s, %s) into track %d", track.Number, track.Title,
+					FormatDuration(duration.Seconds()), tracks[i-1].Number)
+			}
+			tracks = slices.Delete(tracks, i, i+1)
+			i--
+		default:
+			return nil, fmt.Errorf("unknown short track mode %d", opts.ShortTracks)
+		}
+	}
+	cue.Tracks = tracks
+
+	if len(skipped) == 0 {
+		return opts, nil
+	}
+	if opts.Mode == ModeChapterize {
+		return nil, fmt.Errorf("short tracks cannot be skipped in chapters mode; merge them instead")
+	}
+	if opts.Gapless {
+		return nil, fmt.Errorf("gapless verification cannot be combined with skipping short tracks; merge them instead")
+	}
+
+	var wanted []int
+	for _, n := range trackNumbers(tracks) {
+		if opts.Tracks.Contains(n) && !slices.Contains(skipped, n) {
+			wanted = append(wanted, n)
+		}
+	}
+	selection := selectionOf(wanted)
+	if opts.Tracks.Contains(0) && len(selection) > 0 && selection[0].From == 1 {
+		// Keep the hidden track 0 of HiddenTrackSeparate; a range ending at 0
+		// would select every track
+		selection[0].From = 0
+	}
+
+	o := *opts
+	o.Tracks = selection
+	return &o, nil
+}
+
+// trackDuration returns how long tracks[i] is under mode, which for the last
+// track needs the audio length in seconds (negative if unknown)
+func trackDuration(tracks []cueparser.Track, i int, audioSeconds float64, mode BoundaryMode) (time.Duration, bool) {
+	start, err := parseCueTime(trackStart(tracks[i], mode), false)
+	if err != nil {
+		return 0, false
+	}
+
+	end := audioSeconds
+	if i < len(tracks)-1 {
+		if end, err = parseCueTime(trackEnd(tracks[i+1], mode), false); err != nil {
+			return 0, false
+		}
+	}
+	if end < 0 {
+		return 0, false
+	}
+	return time.Duration((end - start) * float64(time.Second)), true
+}
+
+// trackNumbers returns the numbers of tracks in order
+func trackNumbers(tracks []cueparser.Track) []int {
+	numbers := make([]int, len(tracks))
+	for i, track := range tracks {
+		numbers[i] = track.Number
+	}
+	return numbers
+}
+
+// selectionOf returns the selection of exactly the given ascending numbers,
+// which is empty but not nil when there are none
+func selectionOf(numbers []int) TrackSelection {
+	selection := TrackSelection{}
+	for _, n := range numbers {
+		if last := len(selection) - 1; last >= 0 && selection[last].To == n-1 {
+			selection[last].To = n
+			continue
+		}
+		selection = append(selection, TrackRange{From: n, To: n})
+	}
+	return selection
+}
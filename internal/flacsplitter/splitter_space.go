@@ -0,0 +1,75 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// spaceMargin is the factor applied to the estimated output size, covering
+// frame headers, tags and copied pictures
+const spaceMargin = 1.1
+
+// checkOutputSpace verifies that opts.OutputDir is writable and has room for
+// the tracks split from flacPath, so a long batch fails before writing
+// anything rather than when the disk fills up
+func checkOutputSpace(flacPath string, opts *SplitOptions) error {
+	probe, err := os.CreateTemp(opts.OutputDir, ".flac-splitter-probe-*")
+	if err != nil {
+		return fmt.Errorf("output directory %s is not writable: %w", opts.OutputDir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	required, err := estimateOutputSize(flacPath, opts)
+	if err != nil {
+		return err
+	}
+	available, err := freeSpace(opts.OutputDir)
+	if err != nil {
+		// Not every filesystem reports free space; don't block the split on it
+		log.Printf("  Warning: Cannot determine free space in %s: %v", opts.OutputDir, err)
+		return nil
+	}
+
+	if required > available {
+		return fmt.Errorf("not enough free space in %s: about %d MB needed, %d MB available (use --no-space-check to skip this check)",
+			opts.OutputDir, required>>20, available>>20)
+	}
+	return nil
+}
+
+// estimateOutputSize estimates the bytes written when splitting flacPath.
+// WAV output is about the size of the decoded PCM. FLAC output, from the
+// pure Go encoder or an external one, is compressed again and needs about
+// the size of the source.
+func estimateOutputSize(flacPath string, opts *SplitOptions) (uint64, error) {
+	stat, err := os.Stat(flacPath)
+	if err != nil {
+		return 0, err
+	}
+	size := uint64(stat.Size())
+
+	if opts.OutputFormat == OutputWAV {
+		if info, err := probeStreamInfo(flacPath); err == nil && info.NSamples > 0 {
+			bytesPerSample := (uint64(info.BitsPerSample) + 7) / 8
+			size = info.NSamples * uint64(info.NChannels) * bytesPerSample
+		}
+	}
+
+	return uint64(float64(size) * spaceMargin), nil
+}
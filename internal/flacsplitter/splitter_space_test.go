@@ -0,0 +1,66 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEstimateOutputSize(t *testing.T) {
+	cue, flacPath, _ := writeTestAlbum(t, 2,
+		"  TRACK 01 AUDIO", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    INDEX 01 00:01:00",
+	)
+	stat, err := os.Stat(flacPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm := uint64(2 * testSampleRate * 2 * 2)
+
+	opts := testOptions(t)
+	flacEstimate, err := estimateOutputSize(flacPath, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(float64(stat.Size()) * spaceMargin); flacEstimate != want {
+		t.Errorf("FLAC estimate = %d, want %d from the source size", flacEstimate, want)
+	}
+
+	// The tracks pure Go mode writes fit in the estimate
+	if err := Split(cue, flacPath, opts); err != nil {
+		t.Fatal(err)
+	}
+	var written uint64
+	for _, track := range outputTracks(t, opts.OutputDir) {
+		info, err := os.Stat(track)
+		if err != nil {
+			t.Fatal(err)
+		}
+		written += uint64(info.Size())
+	}
+	if written > flacEstimate {
+		t.Errorf("pure Go mode wrote %d bytes, more than the estimated %d", written, flacEstimate)
+	}
+
+	opts.OutputFormat = OutputWAV
+	wavEstimate, err := estimateOutputSize(flacPath, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(float64(pcm) * spaceMargin); wavEstimate != want {
+		t.Errorf("WAV estimate = %d, want %d from the PCM size", wavEstimate, want)
+	}
+}
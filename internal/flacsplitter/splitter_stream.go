@@ -0,0 +1,177 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// trackWriter stores one output track. encode writes the track's audio and
+// tag, when not nil, fills its Vorbis comments.
+type trackWriter func(track cueparser.Track, encode func(ws io.WriteSeeker) error,
+	tag func(cmts *flacvorbis.MetaDataBlockVorbisComment)) error
+
+// fileTracks returns a trackWriter storing the tracks of cue at
+// trackOutputPath, tagging them with the given pictures and running the
+// track hook on them
+func fileTracks(cue cueparser.CueFile, opts *SplitOptions, pictures []*flac.MetaDataBlock) trackWriter {
+	return func(track cueparser.Track, encode func(ws io.WriteSeeker) error,
+		tag func(cmts *flacvorbis.MetaDataBlockVorbisComment)) error {
+		outputFile := trackOutputPath(track, opts)
+		if !opts.OverwriteFiles {
+			if _, err := os.Stat(outputFile); err == nil {
+				return fmt.Errorf("%w: %s", errOutputExists, outputFile)
+			}
+		}
+		if err := writeFileAtomic(outputFile, encode); err != nil {
+			return err
+		}
+
+		// Write metadata tags
+		if tag != nil && opts.Fingerprint {
+			tag = withFingerprint(tag, outputFile, opts)
+		}
+		if tag != nil {
+			if err := updateVorbisComment(outputFile, pictures, opts.PaddingBytes, withTagLog(tag, track, opts)); err != nil {
+				log.Printf("  Warning: Failed to write tags for track %d: %v", track.Number, err)
+			}
+		}
+		return runTrackHook(cue, track, outputFile, opts)
+	}
+}
+
+// TrackWriterFunc returns the destination of an output track. The writer is
+// closed once the track is written if it implements io.Closer.
+type TrackWriterFunc func(track cueparser.Track) (io.Writer, error)
+
+// SplitStream performs a pure Go split of the FLAC stream in r entirely in
+// memory: every track is encoded and tagged into a buffer, then copied to the
+// writer newTrack returns for it. Options apply as in pure Go mode, except
+// that OutputDir, FilenamePattern and OpenReader are not used.
+func SplitStream(r io.ReadSeeker, cue cueparser.CueFile, newTrack TrackWriterFunc, opts *SplitOptions) error {
+	if err := prepareCue(&cue, opts); err != nil {
+		return err
+	}
+	if opts.OutputFormat != "" && opts.OutputFormat != OutputFLAC && opts.OutputFormat != OutputWAV {
+		return fmt.Errorf("unknown output format %q", opts.OutputFormat)
+	}
+	if err := validateNumberFormats(opts); err != nil {
+		return err
+	}
+
+	pictures, err := readPicturesFrom(r)
+	if err != nil {
+		log.Printf("  Warning: Failed to read embedded pictures: %v", err)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	stream, err := NewFlacReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to open FLAC stream: %v", err)
+	}
+	defer stream.Close()
+
+	return splitAudio(cue, stream, streamTracks(newTrack, pictures, opts), opts)
+}
+
+// streamTracks returns a trackWriter that encodes and tags each track in
+// memory before copying it to the writer from newTrack. Tracks may be encoded
+// concurrently, but newTrack is only called by one at a time.
+func streamTracks(newTrack TrackWriterFunc, pictures []*flac.MetaDataBlock, opts *SplitOptions) trackWriter {
+	var mu sync.Mutex
+	return func(track cueparser.Track, encode func(ws io.WriteSeeker) error,
+		tag func(cmts *flacvorbis.MetaDataBlockVorbisComment)) error {
+		buf := &memWriteSeeker{}
+		if err := encode(buf); err != nil {
+			return err
+		}
+
+		data := buf.data
+		if tag != nil {
+			f, err := flac.ParseBytes(bytes.NewReader(data))
+			if err == nil {
+				err = retag(f, pictures, withTagLog(tag, track, opts))
+			}
+			if err == nil {
+				setPadding(f, opts.PaddingBytes)
+			}
+			if err != nil {
+				log.Printf("  Warning: Failed to write tags for track %d: %v", track.Number, err)
+			} else {
+				data = f.Marshal()
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		w, err := newTrack(track)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		if closer, ok := w.(io.Closer); ok {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}
+}
+
+// memWriteSeeker is an in-memory io.WriteSeeker, letting encoders back-patch
+// headers without a file
+type memWriteSeeker struct {
+	data []byte
+	pos  int
+}
+
+func (m *memWriteSeeker) Write(p []byte) (int, error) {
+	if end := m.pos + len(p); end > len(m.data) {
+		m.data = append(m.data, make([]byte, end-len(m.data))...)
+	}
+	n := copy(m.data[m.pos:], p)
+	m.pos += n
+	return n, nil
+}
+
+func (m *memWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = int64(m.pos) + offset
+	case io.SeekEnd:
+		pos = int64(len(m.data)) + offset
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("negative position %d", pos)
+	}
+	m.pos = int(pos)
+	return pos, nil
+}
@@ -0,0 +1,496 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// Internal metadata fields that can be mapped to Vorbis comment names
+const (
+	FieldTitle        = "title"
+	FieldArtist       = "artist"
+	FieldAlbum        = "album"
+	FieldPerformer    = "performer"
+	FieldAlbumArtist  = "albumartist"
+	FieldTrackNumber  = "tracknumber"
+	FieldTotalTracks  = "totaltracks"
+	FieldComposer     = "composer"
+	FieldSongwriter   = "songwriter"
+	FieldDate         = "date"
+	FieldGenre        = "genre"
+	FieldComment      = "comment"
+	FieldCatalog      = "catalog"
+	FieldDiscID       = "discid"
+	FieldDiscNumber   = "discnumber"
+	FieldTotalDiscs   = "totaldiscs"
+	FieldTrackGain    = "replaygain_track_gain"
+	FieldTrackPeak    = "replaygain_track_peak"
+	FieldAlbumGain    = "replaygain_album_gain"
+	FieldAlbumPeak    = "replaygain_album_peak"
+	FieldChannelMask  = "channelmask"
+	FieldFingerprint  = "acoustid_fingerprint"
+	CustomFieldPrefix = "custom:" // e.g. "custom:SOURCE" selects REM SOURCE
+)
+
+// TagMapping maps internal metadata fields to the Vorbis comment names they
+// are written as. Fields mapped to no names are not written.
+type TagMapping map[string][]string
+
+// DefaultTagMapping returns the built-in field to Vorbis comment mapping
+func DefaultTagMapping() TagMapping {
+	return TagMapping{
+		FieldTitle:       {flacvorbis.FIELD_TITLE},
+		FieldArtist:      {flacvorbis.FIELD_ARTIST},
+		FieldAlbum:       {flacvorbis.FIELD_ALBUM},
+		FieldPerformer:   {flacvorbis.FIELD_PERFORMER},
+		FieldAlbumArtist: {"ALBUMARTIST"},
+		FieldTrackNumber: {flacvorbis.FIELD_TRACKNUMBER},
+		FieldTotalTracks: {"TRACKTOTAL"},
+		FieldComposer:    {"COMPOSER"},
+		FieldSongwriter:  {"SONGWRITER"},
+		FieldDate:        {flacvorbis.FIELD_DATE},
+		FieldGenre:       {flacvorbis.FIELD_GENRE},
+		FieldComment:     {flacvorbis.FIELD_DESCRIPTION},
+		FieldCatalog:     {"CATALOG"},
+		FieldDiscID:      {"DISCID"},
+		FieldDiscNumber:  {"DISCNUMBER"},
+		FieldTotalDiscs:  {"DISCTOTAL"},
+		FieldTrackGain:   {"REPLAYGAIN_TRACK_GAIN"},
+		FieldTrackPeak:   {"REPLAYGAIN_TRACK_PEAK"},
+		FieldAlbumGain:   {"REPLAYGAIN_ALBUM_GAIN"},
+		FieldAlbumPeak:   {"REPLAYGAIN_ALBUM_PEAK"},
+		FieldChannelMask: {"WAVEFORMATEXTENSIBLE_CHANNEL_MASK"},
+		FieldFingerprint: {"ACOUSTID_FINGERPRINT"},
+	}
+}
+
+// Set parses a "field=NAME[,NAME...]" entry and replaces the mapping for
+// field; an empty name list disables the field
+func (m TagMapping) Set(entry string) error {
+	field, names, ok := strings.Cut(entry, "=")
+	if !ok {
+		return fmt.Errorf("invalid tag mapping %q: expected field=NAME[,NAME]", entry)
+	}
+
+	field = strings.ToLower(strings.TrimSpace(field))
+	if key, isCustom := strings.CutPrefix(field, CustomFieldPrefix); isCustom {
+		field = CustomFieldPrefix + strings.ToUpper(key)
+	} else if _, known := DefaultTagMapping()[field]; !known {
+		return fmt.Errorf("invalid tag mapping %q: unknown field %q", entry, field)
+	}
+
+	var mapped []string
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.ToUpper(strings.TrimSpace(name)); name != "" {
+			mapped = append(mapped, name)
+		}
+	}
+	m[field] = mapped
+	return nil
+}
+
+// tagValue is a metadata value keyed by its internal field name
+type tagValue struct {
+	field string
+	value string
+}
+
+// trackSource holds properties of the source audio carried into every track
+type trackSource struct {
+	channels uint8                 // Channel count (0 if unknown)
+	pictures []*flac.MetaDataBlock // PICTURE blocks copied verbatim (nil keeps the track's own)
+}
+
+// readPictures returns the PICTURE blocks of a FLAC file without reading its audio
+func readPictures(flacPath string) ([]*flac.MetaDataBlock, error) {
+	file, err := os.Open(flacPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readPicturesFrom(file)
+}
+
+// readPicturesFrom returns the PICTURE metadata blocks of the FLAC stream in r
+func readPicturesFrom(r io.ReadSeeker) ([]*flac.MetaDataBlock, error) {
+	// go-flac expects the fLaC marker at the start of the reader
+	if _, err := skipID3v2(r); err != nil {
+		return nil, err
+	}
+
+	f, err := flac.ParseMetadata(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FLAC metadata: %v", err)
+	}
+
+	var pictures []*flac.MetaDataBlock
+	for _, block := range f.Meta {
+		if block.Type == flac.Picture {
+			pictures = append(pictures, block)
+		}
+	}
+	return pictures, nil
+}
+
+// sourcePictures reads the PICTURE blocks of a FLAC source, logging instead
+// of failing when they cannot be read; other formats have none, and none are
+// embedded when opts.ExtractCoverTo writes the cover to a file instead
+func sourcePictures(audioPath string, opts *SplitOptions) []*flac.MetaDataBlock {
+	if detectAudioFormat(audioPath) != FormatFLAC || opts.ExtractCoverTo != "" {
+		return nil
+	}
+	pictures, err := readPictures(audioPath)
+	if err != nil {
+		log.Printf("  Warning: Failed to read embedded pictures: %v", err)
+		return nil
+	}
+	if len(pictures) > 0 {
+		log.Printf("  Copying %d embedded picture(s) into every track", len(pictures))
+	}
+	return pictures
+}
+
+// writeFlacTags writes metadata tags to a FLAC file along with the source's
+// channel layout and pictures
+func writeFlacTags(flacPath string, cue cueparser.CueFile, track cueparser.Track, trackNum int, src trackSource, opts *SplitOptions) error {
+	fill := trackTags(cue, track, trackNum, src, opts)
+	if opts.Fingerprint {
+		fill = withFingerprint(fill, flacPath, opts)
+	}
+	return updateVorbisComment(flacPath, src.pictures, opts.PaddingBytes, withTagLog(fill, track, opts))
+}
+
+// withTagLog wraps fill so that, with opts.Verbose, the comments it adds are
+// logged as one block per track (tracks may be tagged concurrently)
+func withTagLog(fill func(cmts *flacvorbis.MetaDataBlockVorbisComment), track cueparser.Track,
+	opts *SplitOptions) func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+	if !opts.Verbose {
+		return fill
+	}
+	return func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+		before := len(cmts.Comments)
+		fill(cmts)
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "  Tags for track %d:", track.Number)
+		for _, comment := range cmts.Comments[before:] {
+			b.WriteString("\n    " + comment)
+		}
+		log.Print(b.String())
+	}
+}
+
+// trackTags returns the fill function adding a track's Vorbis comments
+func trackTags(cue cueparser.CueFile, track cueparser.Track, trackNum int, src trackSource, opts *SplitOptions) func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+	values := []tagValue{
+		{FieldTitle, track.Title},
+		{FieldArtist, track.Performer},
+		{FieldTrackNumber, formatNumber(strconv.Itoa(trackNum), strconv.Itoa(len(cue.Tracks)), opts.TrackNumberFormat)},
+		{FieldTotalTracks, strconv.Itoa(len(cue.Tracks))},
+		// Track-level credits take precedence over album-level ones
+		{FieldComposer, cmp.Or(track.Composer, cue.Composer)},
+		{FieldSongwriter, cmp.Or(track.Songwriter, cue.Songwriter)},
+		{FieldTrackGain, track.ReplayGainTrackGain},
+		{FieldTrackPeak, track.ReplayGainTrackPeak},
+		{FieldChannelMask, channelMaskTag(src.channels)},
+	}
+	values = append(values, albumTagValues(cue, opts)...)
+
+	return func(cmts *flacvorbis.MetaDataBlockVorbisComment) {
+		addMappedTags(cmts, values, cue, track.CustomFields, opts)
+	}
+}
+
+// albumTagValues returns the album-level values shared by every output file
+func albumTagValues(cue cueparser.CueFile, opts *SplitOptions) []tagValue {
+	return []tagValue{
+		{FieldAlbum, cue.Album},
+		{FieldPerformer, cue.Performer},
+		{FieldAlbumArtist, AlbumArtist(cue, opts.VariousArtists)},
+		{FieldDate, cue.Date},
+		{FieldGenre, cue.Genre},
+		{FieldComment, cue.Comment},
+		{FieldCatalog, cue.Catalog},
+		{FieldDiscID, cue.DiscID},
+		{FieldDiscNumber, formatNumber(cue.DiscNumber, cue.TotalDiscs, opts.DiscNumberFormat)},
+		{FieldTotalDiscs, cue.TotalDiscs},
+		{FieldAlbumGain, cue.ReplayGainAlbumGain},
+		{FieldAlbumPeak, cue.ReplayGainAlbumPeak},
+	}
+}
+
+// addMappedTags adds values under their mapped Vorbis comment names, followed
+// by any custom CUE fields selected in the mapping, and stamps the vendor
+// string. Empty values are skipped except for the core title, artist, album
+// and numbering fields.
+func addMappedTags(cmts *flacvorbis.MetaDataBlockVorbisComment, values []tagValue,
+	cue cueparser.CueFile, trackCustom map[string]string, opts *SplitOptions) {
+	if opts.VendorString != "" {
+		cmts.Vendor = opts.VendorString
+	}
+
+	mapping := opts.TagMapping
+	if mapping == nil {
+		mapping = DefaultTagMapping()
+	}
+
+	for _, v := range values {
+		if v.value == "" && !alwaysWritten[v.field] {
+			continue
+		}
+		for _, name := range mapping[v.field] {
+			cmts.Add(name, v.value)
+		}
+	}
+
+	// Custom fields come from the mapping, plus every field when
+	// WriteCustomFields is set; track-level values override album-level ones
+	selected := make(map[string]bool)
+	for field := range mapping {
+		if key, ok := strings.CutPrefix(field, CustomFieldPrefix); ok {
+			selected[key] = true
+		}
+	}
+	if opts.WriteCustomFields {
+		for key := range cue.CustomFields {
+			selected[key] = true
+		}
+		for key := range trackCustom {
+			selected[key] = true
+		}
+	}
+
+	customKeys := make([]string, 0, len(selected))
+	for key := range selected {
+		customKeys = append(customKeys, key)
+	}
+	sort.Strings(customKeys)
+
+	for _, key := range customKeys {
+		value := cmp.Or(trackCustom[key], cue.CustomFields[key])
+		if value == "" {
+			continue
+		}
+
+		names, mapped := mapping[CustomFieldPrefix+key]
+		if !mapped {
+			names = []string{strings.ToUpper(key)}
+		}
+		for _, name := range names {
+			cmts.Add(name, value)
+		}
+	}
+}
+
+// NumberFormat controls how a track or disc number is written
+type NumberFormat string
+
+const (
+	// NumberPlain writes the bare number, e.g. "3" (default)
+	NumberPlain NumberFormat = "plain"
+	// NumberPadded zero-pads the number to the width of the total, and to at
+	// least two digits, e.g. "03"
+	NumberPadded NumberFormat = "padded"
+	// NumberSlashed appends the total, e.g. "3/12"; the number is written
+	// plain when the total is unknown
+	NumberSlashed NumberFormat = "slashed"
+)
+
+// formatNumber renders number, out of total, in the given format. A number
+// that already carries a total, such as a DISCNUMBER of "1/2", loses it
+// first; it stands in for total when that is empty.
+func formatNumber(number, total string, format NumberFormat) string {
+	number, ownTotal, _ := strings.Cut(number, "/")
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return ""
+	}
+	if total == "" {
+		total = strings.TrimSpace(ownTotal)
+	}
+	switch format {
+	case NumberPadded:
+		width := max(2, len(total))
+		if len(number) < width {
+			return strings.Repeat("0", width-len(number)) + number
+		}
+	case NumberSlashed:
+		if total != "" {
+			return number + "/" + total
+		}
+	}
+	return number
+}
+
+// validateNumberFormats rejects unknown track and disc number formats
+func validateNumberFormats(opts *SplitOptions) error {
+	for _, format := range []NumberFormat{opts.TrackNumberFormat, opts.DiscNumberFormat} {
+		switch format {
+		case "", NumberPlain, NumberPadded, NumberSlashed:
+		default:
+			return fmt.Errorf("unknown number format %q (want plain, padded or slashed)", format)
+		}
+	}
+	return nil
+}
+
+// alwaysWritten lists fields written even when empty
+var alwaysWritten = map[string]bool{
+	FieldTitle: true, FieldArtist: true, FieldAlbum: true, FieldPerformer: true,
+	FieldTrackNumber: true, FieldTotalTracks: true,
+}
+
+// AlbumArtist returns the ALBUMARTIST value for an album, switching to
+// VariousArtistsName for compilations when variousArtists is set (see
+// SplitOptions.VariousArtists)
+func AlbumArtist(cue cueparser.CueFile, variousArtists bool) string {
+	if variousArtists {
+		for _, track := range cue.Tracks {
+			if track.Performer != "" && track.Performer != cue.Performer {
+				return VariousArtistsName
+			}
+		}
+	}
+	return cue.Performer
+}
+
+// updateVorbisComment replaces the VorbisComment block of a FLAC file with
+// the comments added by fill and its PADDING with padding bytes. Unless
+// pictures is empty, it also replaces the file's PICTURE blocks with the
+// given ones.
+func updateVorbisComment(flacPath string, pictures []*flac.MetaDataBlock, padding int,
+	fill func(cmts *flacvorbis.MetaDataBlockVorbisComment)) error {
+	// Open the FLAC file
+	f, err := flac.ParseFile(flacPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC file: %v", err)
+	}
+	if err := retag(f, pictures, fill); err != nil {
+		return err
+	}
+	setPadding(f, padding)
+
+	// Save to a temporary file and rename it over the original, so an
+	// interrupted save cannot corrupt the track
+	tmpPath := flacPath + tmpSuffix
+	if err := f.Save(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save FLAC file: %v", err)
+	}
+	if err := os.Rename(tmpPath, flacPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace FLAC file: %v", err)
+	}
+
+	return nil
+}
+
+// retag replaces the VorbisComment block of a parsed FLAC file with the
+// comments added by fill, and its PICTURE blocks with pictures if not empty
+func retag(f *flac.File, pictures []*flac.MetaDataBlock, fill func(cmts *flacvorbis.MetaDataBlockVorbisComment)) error {
+	if len(pictures) > 0 {
+		kept := f.Meta[:0]
+		for _, block := range f.Meta {
+			if block.Type != flac.Picture {
+				kept = append(kept, block)
+			}
+		}
+		f.Meta = append(kept, pictures...)
+	}
+
+	// Get or create VorbisComment metadata block
+	var cmtsmeta *flac.MetaDataBlock
+	for _, meta := range f.Meta {
+		if meta.Type == flac.VorbisComment {
+			cmtsmeta = meta
+			break
+		}
+	}
+
+	var cmts *flacvorbis.MetaDataBlockVorbisComment
+	if cmtsmeta != nil {
+		var err error
+		cmts, err = flacvorbis.ParseFromMetaDataBlock(*cmtsmeta)
+		if err != nil {
+			return fmt.Errorf("failed to parse vorbis comment: %v", err)
+		}
+	} else {
+		cmts = flacvorbis.New()
+	}
+
+	// Clear existing comments to avoid duplicates
+	cmts.Comments = nil
+
+	fill(cmts)
+
+	// Marshal to metadata block
+	res := cmts.Marshal()
+
+	// Update or add the VorbisComment block
+	if cmtsmeta != nil {
+		*cmtsmeta = res
+	} else {
+		f.Meta = append(f.Meta, &res)
+	}
+
+	return nil
+}
+
+// DefaultPaddingBytes is the PADDING block size set by DefaultOptions, the
+// same as the reference flac encoder
+const DefaultPaddingBytes = 8192
+
+// maxPaddingBytes is the largest metadata block FLAC can describe
+const maxPaddingBytes = 1<<24 - 1
+
+// validatePadding checks SplitOptions.PaddingBytes
+func validatePadding(opts *SplitOptions) error {
+	if opts.PaddingBytes < 0 || opts.PaddingBytes > maxPaddingBytes {
+		return fmt.Errorf("padding must be 0-%d bytes, got %d", maxPaddingBytes, opts.PaddingBytes)
+	}
+	return nil
+}
+
+// setPadding replaces the PADDING blocks of a parsed FLAC file with a single
+// one of size bytes at the end of the metadata, or none if size is 0, so
+// taggers can later grow the comments without rewriting the audio
+func setPadding(f *flac.File, size int) {
+	kept := f.Meta[:0]
+	for _, block := range f.Meta {
+		if block.Type != flac.Padding {
+			kept = append(kept, block)
+		}
+	}
+	f.Meta = kept
+	if size > 0 {
+		f.Meta = append(f.Meta, &flac.MetaDataBlock{Type: flac.Padding, Data: make([]byte, size)})
+	}
+}
+
+// tmpSuffix is appended to output paths while they are being written
+const tmpSuffix = ".tmp"
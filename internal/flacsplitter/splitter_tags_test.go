@@ -0,0 +1,44 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import "testing"
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		number, total string
+		format        NumberFormat
+		want          string
+	}{
+		{"3", "12", NumberPlain, "3"},
+		{"3", "12", NumberPadded, "03"},
+		{"3", "120", NumberPadded, "003"},
+		{"3", "12", NumberSlashed, "3/12"},
+		{"3", "", NumberSlashed, "3"},
+		{"", "12", NumberSlashed, ""},
+		{"1/2", "2", NumberSlashed, "1/2"},
+		{"1/2", "", NumberSlashed, "1/2"},
+		{"1/2", "3", NumberSlashed, "1/3"},
+		{"1/2", "", NumberPlain, "1"},
+		{"1/2", "", NumberPadded, "01"},
+		{" 1 / 2 ", "", NumberSlashed, "1/2"},
+	}
+
+	for _, tt := range tests {
+		if got := formatNumber(tt.number, tt.total, tt.format); got != tt.want {
+			t.Errorf("formatNumber(%q, %q, %s) = %q, want %q", tt.number, tt.total, tt.format, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+func TestResolveOutputNamesCase(t *testing.T) {
+	cue := cueparser.CueFile{Tracks: []cueparser.Track{
+		{Number: 1, Title: "Intro", Index: "00:00:00", Type: "AUDIO"},
+		{Number: 2, Title: "intro", Index: "01:00:00", Type: "AUDIO"},
+	}}
+
+	defer func(saved bool) { caseInsensitivePaths = saved }(caseInsensitivePaths)
+	for _, insensitive := range []bool{false, true} {
+		caseInsensitivePaths = insensitive
+
+		opts := DefaultOptions("out")
+		opts.FilenamePattern = "%[2]s.flac"
+		err := resolveOutputNames(cue, opts)
+		if got := errors.Is(err, ErrDuplicateOutput); got != insensitive {
+			t.Errorf("case-insensitive %t: resolveOutputNames() error = %v, want a duplicate %t", insensitive, err, insensitive)
+		}
+
+		opts.RenameDuplicates = true
+		if err := resolveOutputNames(cue, opts); err != nil {
+			t.Fatal(err)
+		}
+		want := filepath.Join("out", "intro.flac")
+		if insensitive {
+			want = filepath.Join("out", "intro (2).flac")
+		}
+		if got := trackOutputPath(cue.Tracks[1], opts); got != want {
+			t.Errorf("case-insensitive %t: track 2 is written to %q, want %q", insensitive, got, want)
+		}
+	}
+}
+
+func TestParseCueTime(t *testing.T) {
+	tests := []struct {
+		cueTime string
+		strict  bool
+		want    float64
+		wantErr bool
+	}{
+		{"00:00:00", true, 0, false},
+		{"03:25:30", true, 205.4, false},
+		{"99:59:74", true, 5999 + 74.0/75, false},
+		{"105:00:12", true, 6300.16, false},
+		{"01:45:00:12", true, 6300.16, false},
+		{"00:99:59:74", false, 5999 + 74.0/75, false},
+		{"00:99:59:74", true, 0, true},
+		{"01:60:00:00", true, 0, true},
+		{"01:45:00:75", true, 0, true},
+		{"01:45:00:75", false, 6301, false},
+		{"1:2:3:4:5", false, 0, true},
+		{"01:-45:00:00", false, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCueTime(tt.cueTime, tt.strict)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCueTime(%q, %t) error = %v, want error %t", tt.cueTime, tt.strict, err, tt.wantErr)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("parseCueTime(%q, %t) = %v, want %v", tt.cueTime, tt.strict, got, tt.want)
+		}
+	}
+}
+
+func TestSplitRejectsUnparseableTimecode(t *testing.T) {
+	// Frame overflow is renormalized without StrictTimecodes, but an index
+	// that cannot be read at all fails the album instead of cutting at 0
+	cue, flacPath, _ := writeTestAlbum(t, 3,
+		"  TRACK 01 AUDIO", "    TITLE \"One\"", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    TITLE \"Two\"", "    INDEX 01 00:01:80",
+	)
+	if err := Split(cue, flacPath, testOptions(t)); err != nil {
+		t.Fatalf("Split() with frame overflow = %v, want success", err)
+	}
+
+	cue, flacPath, _ = writeTestAlbum(t, 3,
+		"  TRACK 01 AUDIO", "    TITLE \"One\"", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    TITLE \"Two\"", "    INDEX 01 00:0x:00",
+	)
+	err := Split(cue, flacPath, testOptions(t))
+	if !errors.Is(err, ErrInvalidTimecode) {
+		t.Fatalf("Split() error = %v, want %v", err, ErrInvalidTimecode)
+	}
+	if want := "track 2 INDEX 01"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Split() error = %v, want it to name %q", err, want)
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// ExternalSplitter describes an external tool that can split an album. A
+// splitter either extracts one track per invocation (Args) or handles the
+// whole album itself (Split); in both cases the tracks are tagged afterwards.
+type ExternalSplitter struct {
+	// Name identifies the splitter for SplitOptions.Tool
+	Name string
+
+	// Command is the executable run for each track
+	Command string
+
+	// Available reports whether the tool can be used (nil looks up Command in PATH)
+	Available func() bool
+
+	// Supports reports whether the tool handles this album correctly; automatic
+	// selection skips splitters that return false (nil supports everything)
+	Supports func(cue cueparser.CueFile, audioPath string) bool
+
+	// Args builds the Command arguments that extract a single track
+	Args func(job TrackJob) []string
+
+	// Split, if set, splits the whole album instead of running Args per track
+	Split func(cue cueparser.CueFile, audioPath string, opts *SplitOptions) error
+}
+
+// TrackJob describes a single track extraction for ExternalSplitter.Args
+type TrackJob struct {
+	Input    string // Source audio file
+	Output   string // FLAC file to create (may already exist when overwriting)
+	Start    string // Start offset in seconds
+	Duration string // Length in seconds, empty for the last track
+
+	// SampleRate is the source sample rate when it could be probed, in which
+	// case StartSample and EndSample give the same range sample-precisely, as
+	// pure Go mode cuts it. EndSample is 0 for the last track.
+	SampleRate  uint32
+	StartSample uint64
+	EndSample   uint64
+}
+
+// Built-in external splitters
+var (
+	shnsplitSplitter = ExternalSplitter{
+		Name:     "shnsplit",
+		Command:  "shnsplit",
+		Supports: isCDQuality,
+		Split:    splitWithShnsplit,
+	}
+
+	ffmpegSplitter = ExternalSplitter{
+		Name:    "ffmpeg",
+		Command: "ffmpeg",
+		Args: func(job TrackJob) []string {
+			// Stream copy and -ss cut at frame or millisecond granularity, so
+			// trim by sample and re-encode when the offsets are known
+			if job.SampleRate > 0 {
+				trim := fmt.Sprintf("atrim=start_sample=%d", job.StartSample)
+				if job.EndSample > 0 {
+					trim += fmt.Sprintf(":end_sample=%d", job.EndSample)
+				}
+				return []string{"-i", job.Input, "-af", trim + ",asetpts=PTS-STARTPTS",
+					"-acodec", "flac", "-y", job.Output}
+			}
+
+			args := []string{"-i", job.Input, "-ss", job.Start}
+			if job.Duration != "" {
+				args = append(args, "-t", job.Duration)
+			}
+
+			// Stream copy only works for FLAC input; other formats are re-encoded
+			if detectAudioFormat(job.Input) == FormatFLAC {
+				args = append(args, "-acodec", "copy")
+			} else {
+				args = append(args, "-acodec", "flac")
+			}
+
+			return append(args, "-y", job.Output)
+		},
+	}
+
+	soxSplitter = ExternalSplitter{
+		Name:    "sox",
+		Command: "sox",
+		Args: func(job TrackJob) []string {
+			// A trailing "s" makes sox count in samples instead of seconds
+			if job.SampleRate > 0 {
+				args := []string{job.Input, job.Output, "trim", fmt.Sprintf("%ds", job.StartSample)}
+				if job.EndSample > 0 {
+					args = append(args, fmt.Sprintf("%ds", job.EndSample-job.StartSample))
+				}
+				return args
+			}
+
+			args := []string{job.Input, job.Output, "trim", job.Start}
+			if job.Duration != "" {
+				args = append(args, job.Duration)
+			}
+			return args
+		},
+	}
+)
+
+// builtinSplitters lists the built-in splitters in default preference order
+var builtinSplitters = []ExternalSplitter{shnsplitSplitter, ffmpegSplitter, soxSplitter}
+
+// available reports whether the splitter can be used on this system
+func (s *ExternalSplitter) available() bool {
+	if s.Available != nil {
+		return s.Available()
+	}
+	return executableExists(s.Command)
+}
+
+// supports reports whether the splitter handles the given album. Whole-album
+// splitters cut and name tracks as the CUE sheet says, so they can neither
+// drop gaps nor rename duplicate tracks.
+func (s *ExternalSplitter) supports(cue cueparser.CueFile, audioPath string, opts *SplitOptions) bool {
+	if (opts.BoundaryMode == BoundaryDropGaps || len(opts.outputNames) > 0) && s.Split != nil {
+		return false
+	}
+	return s.Supports == nil || s.Supports(cue, audioPath)
+}
+
+// isCDQuality reports whether the audio is 44.1 kHz 16-bit stereo. shnsplit
+// refuses CUE frame timecodes (MM:SS:FF) for anything else. Non-FLAC sources
+// cannot be probed here and are assumed to qualify.
+func isCDQuality(_ cueparser.CueFile, audioPath string) bool {
+	if detectAudioFormat(audioPath) != FormatFLAC {
+		return true
+	}
+	info, err := probeStreamInfo(audioPath)
+	if err != nil {
+		return true
+	}
+	return info.SampleRate == 44100 && info.BitsPerSample == 16 && info.NChannels == 2
+}
+
+// run splits the album with this splitter and tags the resulting tracks
+func (s *ExternalSplitter) run(cue cueparser.CueFile, audioPath string, opts *SplitOptions) error {
+	if s.Split != nil {
+		return s.Split(cue, audioPath, opts)
+	}
+	if s.Args == nil {
+		return fmt.Errorf("splitter %s has neither Args nor Split", s.Name)
+	}
+
+	// Sample offsets come from the same boundaries pure Go mode uses, so both
+	// backends cut at identical samples
+	var boundaries []TrackBoundary
+	var sampleRate uint32
+	if detectAudioFormat(audioPath) == FormatFLAC {
+		if info, totalSamples, err := sourceLength(audioPath, opts); err == nil && info.SampleRate > 0 {
+			if totalSamples == 0 {
+				totalSamples = math.MaxUint64
+			}
+			sampleRate = info.SampleRate
+			boundaries = CalculateBoundariesWithMode(cue, sampleRate, totalSamples, opts.BoundaryMode)
+		}
+	}
+
+	var failed []string
+	for i, track := range cue.Tracks {
+		if !opts.Tracks.Contains(track.Number) {
+			continue
+		}
+		job := TrackJob{
+			Input:  audioPath,
+			Output: trackOutputPath(track, opts),
+			Start:  convertCueTimeToSeconds(trackStart(track, opts.BoundaryMode)),
+		}
+		if i < len(cue.Tracks)-1 {
+			job.Duration = calculateDuration(trackStart(track, opts.BoundaryMode), trackEnd(cue.Tracks[i+1], opts.BoundaryMode))
+		}
+		if boundaries != nil {
+			job.SampleRate = sampleRate
+			job.StartSample = boundaries[i].StartSample
+			if i < len(cue.Tracks)-1 {
+				job.EndSample = boundaries[i].EndSample
+			}
+		}
+
+		if !opts.OverwriteFiles {
+			if _, err := os.Stat(job.Output); err == nil {
+				log.Printf("  Warning: Skipping track %d, output file already exists: %s", track.Number, job.Output)
+				continue
+			}
+		}
+
+		// Only the failed track is retried, never the whole album
+		output, attempts, err := runExternalRetry(opts, s.Command, s.Args(job)...)
+		if errors.Is(err, ErrExternalTimeout) {
+			return fmt.Errorf("track %d: %w", track.Number, err)
+		}
+		if err != nil {
+			log.Printf("  Warning: Failed to extract track %d after %d attempt(s): %v", track.Number, attempts, err)
+			log.Printf("  %s output: %s", s.Name, string(output))
+			failed = append(failed, strconv.Itoa(track.Number))
+			continue
+		}
+		if attempts > 1 {
+			log.Printf("  Track %d extracted after %d attempts", track.Number, attempts)
+		}
+	}
+
+	if len(failed) > 0 {
+		log.Printf("  Split with %s finished; tracks that failed: %s", s.Name, strings.Join(failed, ", "))
+	} else {
+		log.Printf("  Split complete with %s", s.Name)
+	}
+
+	// Apply metadata tags using go-flac
+	return applyMetadataTags(cue, audioPath, opts)
+}
+
+// splitters returns the registered splitters: those from opts first, so they
+// can replace a built-in of the same name, then the built-ins
+func splitters(opts *SplitOptions) []ExternalSplitter {
+	return append(append([]ExternalSplitter(nil), opts.Splitters...), builtinSplitters...)
+}
+
+// selectSplitter picks the external splitter for an album. Precedence:
+//
+//  1. opts.Tool, if set, is used as long as it is installed
+//  2. ffmpeg, if opts.UseFFmpeg is set and ffmpeg is installed
+//  3. the first installed splitter in registry order (opts.Splitters, then
+//     shnsplit, ffmpeg, sox) whose Supports accepts the album
+//  4. the first installed splitter, even if it may mishandle the album
+func selectSplitter(cue cueparser.CueFile, audioPath string, opts *SplitOptions) (*ExternalSplitter, error) {
+	all := splitters(opts)
+
+	if opts.Tool != "" {
+		splitter := findSplitter(all, opts.Tool)
+		if splitter == nil {
+			return nil, fmt.Errorf("unknown splitter %q", opts.Tool)
+		}
+		if !splitter.available() {
+			return nil, fmt.Errorf("%w: %s is not installed - please install it", ErrNoSplitter, opts.Tool)
+		}
+		return splitter, nil
+	}
+
+	if opts.UseFFmpeg {
+		if splitter := findSplitter(all, ffmpegSplitter.Name); splitter != nil && splitter.available() {
+			return splitter, nil
+		}
+	}
+
+	var fallback *ExternalSplitter
+	names := make([]string, 0, len(all))
+	for i := range all {
+		names = append(names, all[i].Name)
+		if !all[i].available() {
+			continue
+		}
+		if all[i].supports(cue, audioPath, opts) {
+			return &all[i], nil
+		}
+		if fallback == nil {
+			fallback = &all[i]
+		}
+	}
+
+	if fallback != nil {
+		log.Printf("  Warning: %s may not handle this album correctly, but no better splitter is installed", fallback.Name)
+		return fallback, nil
+	}
+
+	return nil, fmt.Errorf("%w - please install one of: %s", ErrNoSplitter, strings.Join(names, ", "))
+}
+
+// findSplitter returns the first splitter with the given name, or nil
+func findSplitter(all []ExternalSplitter, name string) *ExternalSplitter {
+	for i := range all {
+		if all[i].Name == name {
+			return &all[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+)
+
+// fakeInstalled makes lookPath find only the named commands until the test ends
+func fakeInstalled(t *testing.T, commands ...string) {
+	t.Helper()
+	saved := lookPath
+	t.Cleanup(func() { lookPath = saved })
+	lookPath = func(name string) (string, error) {
+		if slices.Contains(commands, name) {
+			return "/usr/bin/" + name, nil
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+func TestSelectSplitter(t *testing.T) {
+	dir := t.TempDir()
+	cdQuality := filepath.Join(dir, "cd.flac")
+	writeTestFlac(t, cdQuality, testSignal(2, testSampleRate/10))
+	mono := filepath.Join(dir, "mono.flac")
+	writeTestFlac(t, mono, testSignal(1, testSampleRate/10))
+
+	custom := ExternalSplitter{
+		Name:      "custom",
+		Available: func() bool { return true },
+		Args:      func(TrackJob) []string { return nil },
+	}
+
+	tests := []struct {
+		name      string
+		installed []string
+		audio     string
+		opts      SplitOptions
+		want      string
+		wantErr   error
+	}{
+		{"shnsplit first", []string{"shnsplit", "ffmpeg", "sox"}, cdQuality, SplitOptions{}, "shnsplit", nil},
+		{"ffmpeg when shnsplit is missing", []string{"ffmpeg", "sox"}, cdQuality, SplitOptions{}, "ffmpeg", nil},
+		{"sox as the last resort", []string{"sox"}, cdQuality, SplitOptions{}, "sox", nil},
+		{"UseFFmpeg beats shnsplit", []string{"shnsplit", "ffmpeg"}, cdQuality, SplitOptions{UseFFmpeg: true}, "ffmpeg", nil},
+		{"UseFFmpeg without ffmpeg", []string{"shnsplit", "sox"}, cdQuality, SplitOptions{UseFFmpeg: true}, "shnsplit", nil},
+		{"Tool beats UseFFmpeg", []string{"shnsplit", "ffmpeg", "sox"}, cdQuality,
+			SplitOptions{Tool: "sox", UseFFmpeg: true}, "sox", nil},
+		{"Tool not installed", []string{"shnsplit", "ffmpeg"}, cdQuality, SplitOptions{Tool: "sox"}, "", ErrNoSplitter},
+		{"shnsplit skipped for non-CD audio", []string{"shnsplit", "sox"}, mono, SplitOptions{}, "sox", nil},
+		{"shnsplit as a fallback for non-CD audio", []string{"shnsplit"}, mono, SplitOptions{}, "shnsplit", nil},
+		{"shnsplit skipped when dropping gaps", []string{"shnsplit", "ffmpeg"}, cdQuality,
+			SplitOptions{BoundaryMode: BoundaryDropGaps}, "ffmpeg", nil},
+		{"registered splitters first", []string{"shnsplit"}, cdQuality,
+			SplitOptions{Splitters: []ExternalSplitter{custom}}, "custom", nil},
+		{"nothing installed", nil, cdQuality, SplitOptions{}, "", ErrNoSplitter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeInstalled(t, tt.installed...)
+
+			splitter, err := selectSplitter(cueparser.CueFile{}, tt.audio, &tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("selectSplitter() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if splitter.Name != tt.want {
+				t.Errorf("selectSplitter() = %s, want %s", splitter.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectSplitterUnknownTool(t *testing.T) {
+	fakeInstalled(t, "shnsplit", "ffmpeg", "sox")
+
+	if _, err := selectSplitter(cueparser.CueFile{}, "album.flac", &SplitOptions{Tool: "mp3splt"}); err == nil {
+		t.Error("selectSplitter() accepted an unknown tool")
+	}
+}
+
+func TestSampleOffsetArgs(t *testing.T) {
+	job := TrackJob{
+		Input:       "album.flac",
+		Output:      "02.flac",
+		Start:       "1.400",
+		Duration:    "0.800",
+		SampleRate:  testSampleRate,
+		StartSample: 61740,
+		EndSample:   97020,
+	}
+
+	tests := []struct {
+		splitter ExternalSplitter
+		want     []string
+	}{
+		{ffmpegSplitter, []string{"-i", "album.flac", "-af", "atrim=start_sample=61740:end_sample=97020,asetpts=PTS-STARTPTS",
+			"-acodec", "flac", "-y", "02.flac"}},
+		{soxSplitter, []string{"album.flac", "02.flac", "trim", "61740s", "35280s"}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.splitter.Args(job); !slices.Equal(got, tt.want) {
+			t.Errorf("%s args = %q, want %q", tt.splitter.Name, got, tt.want)
+		}
+	}
+}
+
+func TestExternalSplitMatchesPureGo(t *testing.T) {
+	cue, flacPath, _ := writeTestAlbum(t, 3,
+		"  TRACK 01 AUDIO", "    TITLE \"One\"", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    TITLE \"Two\"", "    INDEX 00 00:01:00", "    INDEX 01 00:01:37",
+		"  TRACK 03 AUDIO", "    TITLE \"Three\"", "    INDEX 01 00:02:13",
+	)
+
+	pureGo := testOptions(t)
+	if err := Split(cue, flacPath, pureGo); err != nil {
+		t.Fatal(err)
+	}
+	want := outputTracks(t, pureGo.OutputDir)
+
+	for _, tool := range []string{ffmpegSplitter.Name, soxSplitter.Name} {
+		t.Run(tool, func(t *testing.T) {
+			if !executableExists(tool) {
+				t.Skipf("%s is not installed", tool)
+			}
+
+			opts := testOptions(t)
+			opts.Mode = ModeExternalTools
+			opts.Tool = tool
+			if err := Split(cue, flacPath, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			got := outputTracks(t, opts.OutputDir)
+			if len(got) != len(want) {
+				t.Fatalf("got %d tracks, want %d", len(got), len(want))
+			}
+			for i := range got {
+				diff, err := DiffFlacPCM(want[i], got[i], true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff != nil {
+					t.Errorf("track %d differs from pure Go mode at %v", i+1, diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/ldmonster/flac-splitter/internal/cueparser"
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/meta"
+)
+
+// A last track longer than both limits is likely paired with the wrong audio
+const (
+	longLastTrackFactor  = 4       // times the longest other track
+	longLastTrackSeconds = 10 * 60 // absolute minimum before warning
+)
+
+// checkDuration compares the CUE track layout against the audio length.
+// Audio shorter than the CUE implies is an error; an implausibly long last
+// track suggests the wrong audio file and is logged as a warning.
+func checkDuration(cue cueparser.CueFile, audioSeconds float64) error {
+	warning, err := durationProblems(cue, audioSeconds)
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		log.Printf("  Warning: %s", warning)
+	}
+	return nil
+}
+
+// durationProblems returns an error when the audio is shorter than the CUE
+// layout implies, and otherwise a warning when the last track is implausibly
+// long compared to the others
+func durationProblems(cue cueparser.CueFile, audioSeconds float64) (string, error) {
+	if len(cue.Tracks) == 0 || audioSeconds <= 0 {
+		return "", nil
+	}
+
+	last := cue.Tracks[len(cue.Tracks)-1]
+	lastStart := parseFloat(convertCueTimeToSeconds(last.Index))
+	if lastStart >= audioSeconds {
+		return "", fmt.Errorf("audio is %s long but track %d starts at %s (%s shorter than the CUE implies)",
+			FormatDuration(audioSeconds), last.Number, FormatDuration(lastStart), FormatDuration(lastStart-audioSeconds))
+	}
+
+	var longest float64
+	for i := 0; i < len(cue.Tracks)-1; i++ {
+		start := parseFloat(convertCueTimeToSeconds(cue.Tracks[i].Index))
+		end := parseFloat(convertCueTimeToSeconds(cue.Tracks[i+1].Index))
+		longest = max(longest, end-start)
+	}
+
+	lastLength := audioSeconds - lastStart
+	if len(cue.Tracks) > 1 && lastLength > longLastTrackSeconds && lastLength > longest*longLastTrackFactor {
+		return fmt.Sprintf("last track runs %s, %s longer than any other track - the audio may not belong to this CUE",
+			FormatDuration(lastLength), FormatDuration(lastLength-longest)), nil
+	}
+
+	return "", nil
+}
+
+// FormatDuration renders seconds as MM:SS:FF, rounded to the nearest CD
+// frame like CUE times, so logged positions and lengths compare directly
+// with the CUE sheet. Minutes are not wrapped into hours.
+func FormatDuration(seconds float64) string {
+	if seconds < 0 {
+		return "-" + formatCueTime(-seconds)
+	}
+	return formatCueTime(seconds)
+}
+
+// probeFlacDuration returns the duration in seconds of the FLAC file, or 0
+// when it has no sample rate. Files that do not record their length in
+// STREAMINFO are decoded to count their samples, once per album when opts
+// comes from Split; opts may be nil.
+func probeFlacDuration(flacPath string, opts *SplitOptions) (float64, error) {
+	info, total, err := sourceLength(flacPath, opts)
+	if err != nil {
+		return 0, err
+	}
+	if info.SampleRate == 0 {
+		return 0, nil
+	}
+	return float64(total) / float64(info.SampleRate), nil
+}
+
+// probeSampleCount returns the STREAMINFO block of the FLAC file and its
+// number of samples per channel. Some encoders write 0 (unknown length) for
+// the total, which leaves no choice but to decode the frames and count.
+func probeSampleCount(flacPath string) (*meta.StreamInfo, uint64, error) {
+	stream, err := flac.Open(flacPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open FLAC file: %v", err)
+	}
+	defer stream.Close()
+
+	if stream.Info.NSamples > 0 {
+		return stream.Info, stream.Info.NSamples, nil
+	}
+
+	var total uint64
+	for {
+		f, err := stream.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse frame: %w", err)
+		}
+		total += uint64(f.BlockSize)
+	}
+	return stream.Info, total, nil
+}
+
+// audioLength holds the probed length of an album's source audio. Split
+// shares one between the steps that need the length, so a file without a
+// length in STREAMINFO is decoded to count its samples at most once.
+type audioLength struct {
+	path string
+
+	once    sync.Once
+	info    *meta.StreamInfo
+	samples uint64
+	err     error
+}
+
+// sourceLength returns probeSampleCount(flacPath), probing the file only the
+// first time for the album opts belongs to
+func sourceLength(flacPath string, opts *SplitOptions) (*meta.StreamInfo, uint64, error) {
+	if opts == nil || opts.length == nil || opts.length.path != flacPath {
+		return probeSampleCount(flacPath)
+	}
+	l := opts.length
+	l.once.Do(func() { l.info, l.samples, l.err = probeSampleCount(flacPath) })
+	return l.info, l.samples, l.err
+}
+
+// decoded records the length of the audio pure Go mode decoded from the
+// album's source, which saves the later steps probing it again
+func (l *audioLength) decoded(info *meta.StreamInfo, samples uint64) {
+	if l != nil {
+		l.once.Do(func() { l.info, l.samples = info, samples })
+	}
+}
+
+// probeStreamInfo reads the STREAMINFO block without decoding any audio
+func probeStreamInfo(flacPath string) (*meta.StreamInfo, error) {
+	stream, err := flac.Open(flacPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FLAC file: %v", err)
+	}
+	defer stream.Close()
+
+	return stream.Info, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSourceLengthProbesOnce(t *testing.T) {
+	flacPath := filepath.Join(t.TempDir(), "unknown.flac")
+	writeTestFlac(t, flacPath, testSignal(2, 2*testSampleRate))
+	clearSampleCount(t, flacPath)
+
+	opts := testOptions(t)
+	opts.length = &audioLength{path: flacPath}
+	info, total, err := sourceLength(flacPath, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.NSamples != 0 || total != 2*testSampleRate {
+		t.Fatalf("sourceLength() = NSamples %d, %d samples, want 0 and %d", info.NSamples, total, 2*testSampleRate)
+	}
+
+	// The second call must not touch the file again
+	if err := os.Remove(flacPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, again, err := sourceLength(flacPath, opts); err != nil || again != total {
+		t.Errorf("cached sourceLength() = %d, %v, want %d", again, err, total)
+	}
+	if _, _, err := sourceLength(flacPath, nil); err == nil {
+		t.Error("sourceLength() without an album probed nothing")
+	}
+}
+
+func TestSplitUnknownLength(t *testing.T) {
+	// The last track is too short and is merged into track 2, which needs
+	// the length of the audio
+	cue, flacPath, samples := writeTestAlbum(t, 5,
+		"  TRACK 01 AUDIO", "    TITLE \"One\"", "    INDEX 01 00:00:00",
+		"  TRACK 02 AUDIO", "    TITLE \"Two\"", "    INDEX 01 00:02:00",
+		"  TRACK 03 AUDIO", "    TITLE \"Three\"", "    INDEX 01 00:04:50",
+	)
+	clearSampleCount(t, flacPath)
+	if info, err := probeStreamInfo(flacPath); err != nil || info.NSamples != 0 {
+		t.Fatalf("probeStreamInfo() = %v, %v, want an unknown length", info, err)
+	}
+
+	opts := testOptions(t)
+	opts.MinTrackDuration = time.Second
+	opts.ShortTracks = ShortTrackMerge
+	opts.WritePlaylist = true
+	opts.WriteTracksCue = true
+	if err := Split(cue, flacPath, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	tracks := outputTracks(t, opts.OutputDir)
+	if len(tracks) != 2 {
+		t.Fatalf("got tracks %v, want 2", tracks)
+	}
+	got, _ := readTestFlac(t, tracks[1])
+	want := [][]int32{samples[0][2*testSampleRate:], samples[1][2*testSampleRate:]}
+	if samplesMD5(got) != samplesMD5(want) {
+		t.Errorf("track 2 has %d samples, want the %d up to the end of the audio", len(got[0]), len(want[0]))
+	}
+
+	playlist, err := os.ReadFile(filepath.Join(opts.OutputDir, "Album.m3u8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(playlist), "#EXTINF:3,Artist - Two") {
+		t.Errorf("playlist does not give track 2 its 3s:\n%s", playlist)
+	}
+
+	sheet, err := os.ReadFile(filepath.Join(opts.OutputDir, "Album.cue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := slices.DeleteFunc(strings.Split(string(sheet), "\n"), func(line string) bool {
+		return !strings.HasPrefix(line, "FILE ")
+	})
+	if len(files) != 2 {
+		t.Errorf("tracks CUE lists files %q, want 2", files)
+	}
+}
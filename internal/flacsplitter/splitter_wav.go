@@ -0,0 +1,137 @@
+// Copyright 2026 ldmonster
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flacsplitter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mewkiz/flac/meta"
+)
+
+// waveFormatPCM and waveFormatExtensible are the WAVE fmt chunk format tags
+const (
+	waveFormatPCM        = 0x0001
+	waveFormatExtensible = 0xFFFE
+)
+
+// ksDataFormatSubtypePCM is the KSDATAFORMAT_SUBTYPE_PCM GUID in file byte order
+var ksDataFormatSubtypePCM = [16]byte{
+	0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00,
+	0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71,
+}
+
+// encodeWav writes the given range of the decoded samples as a RIFF/WAVE
+// stream, calling onFrame (if not nil) as blocks of samples are written.
+// Streams with more than two channels or 16 bits use WAVE_FORMAT_EXTENSIBLE.
+func encodeWav(out io.Writer, samples [][]int32, r sampleRange, info *meta.StreamInfo, onFrame func(frameSamples int)) error {
+	if len(samples) == 0 {
+		return fmt.Errorf("no samples to encode")
+	}
+	r.end = min(r.end, uint64(len(samples[0])))
+	if r.start >= r.end {
+		return fmt.Errorf("no samples to encode")
+	}
+
+	numChannels := int(info.NChannels)
+	bps := int(info.BitsPerSample)
+	containerBytes := (bps + 7) / 8
+	shift := uint(containerBytes*8 - bps) // samples are left-justified in their container
+	blockAlign := numChannels * containerBytes
+
+	dataSize := (r.end - r.start) * uint64(blockAlign)
+	extensible := numChannels > 2 || bps > 16
+	fmtSize := 16
+	if extensible {
+		fmtSize = 40
+	}
+	riffSize := 4 + (8 + uint64(fmtSize)) + (8 + dataSize) + dataSize%2
+	if riffSize > math.MaxUint32 {
+		return fmt.Errorf("track is too long for a WAV file (%d bytes of audio)", dataSize)
+	}
+
+	w := bufio.NewWriter(out)
+	le := binary.LittleEndian
+
+	// RIFF header and fmt chunk
+	header := []byte("RIFF")
+	header = le.AppendUint32(header, uint32(riffSize))
+	header = append(header, "WAVEfmt "...)
+	header = le.AppendUint32(header, uint32(fmtSize))
+	if extensible {
+		header = le.AppendUint16(header, waveFormatExtensible)
+	} else {
+		header = le.AppendUint16(header, waveFormatPCM)
+	}
+	header = le.AppendUint16(header, uint16(numChannels))
+	header = le.AppendUint32(header, info.SampleRate)
+	header = le.AppendUint32(header, info.SampleRate*uint32(blockAlign))
+	header = le.AppendUint16(header, uint16(blockAlign))
+	header = le.AppendUint16(header, uint16(containerBytes*8))
+	if extensible {
+		var mask uint32
+		if numChannels < len(channelMasks) {
+			mask = channelMasks[numChannels]
+		}
+		header = le.AppendUint16(header, 22) // size of the extension
+		header = le.AppendUint16(header, uint16(bps))
+		header = le.AppendUint32(header, mask)
+		header = append(header, ksDataFormatSubtypePCM[:]...)
+	}
+	header = append(header, "data"...)
+	header = le.AppendUint32(header, uint32(dataSize))
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write WAV header: %w", err)
+	}
+
+	// Interleaved little-endian samples; 8-bit WAV audio is unsigned
+	buf := make([]byte, 0, DefaultBlockSize*blockAlign)
+	for offset := r.start; offset < r.end; offset += DefaultBlockSize {
+		end := min(offset+DefaultBlockSize, r.end)
+		buf = buf[:0]
+		for i := offset; i < end; i++ {
+			for ch := 0; ch < numChannels; ch++ {
+				sample := uint32(samples[ch][i] << shift)
+				if containerBytes == 1 {
+					sample += 0x80
+				}
+				for b := 0; b < containerBytes; b++ {
+					buf = append(buf, byte(sample>>(8*b)))
+				}
+			}
+		}
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("failed to write samples: %w", err)
+		}
+		if onFrame != nil {
+			onFrame(int(end - offset))
+		}
+	}
+
+	// RIFF chunks are padded to an even size
+	if dataSize%2 == 1 {
+		if err := w.WriteByte(0); err != nil {
+			return fmt.Errorf("failed to write samples: %w", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write samples: %w", err)
+	}
+	return nil
+}